@@ -1,43 +1,144 @@
 package pkg
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
 	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-sdk/sdk/health"
+)
+
+const (
+	// listenerHealthInterval is the interval at which the health of the UDP listener, and the
+	// freshness of the data it receives, is checked.
+	listenerHealthInterval = 30 * time.Second
+
+	// shutdownTimeout is the maximum time to wait for the UDP listeners to finish
+	// processing received data when the plugin terminates.
+	shutdownTimeout = 10 * time.Second
+)
+
+var (
+	// listeners holds the UDP servers started by RunBackgroundListener, so that they
+	// can be shut down when the plugin terminates.
+	listeners   []*protocol.JtiUDPServer
+	listenersMu sync.Mutex
 )
 
 // RunBackgroundListener is a plugin pre-run action which starts the UDP server, listening
 // for incoming streamed data from Juniper equipment.
-var RunBackgroundListener = sdk.PluginAction{
-	Name: "run background JTI listener",
-	Action: func(p *sdk.Plugin) error {
+var RunBackgroundListener = runBackgroundListener(nil)
+
+// runBackgroundListener creates the plugin pre-run action which starts the UDP server,
+// with the given decoders for the sensors which the plugin does not support itself.
+func runBackgroundListener(sensors []jti.SensorDecoder) sdk.PluginAction {
+	return sdk.PluginAction{
+		Name: "run background JTI listener",
+		Action: func(p *sdk.Plugin) error {
+			return startListener(p, sensors)
+		},
+	}
+}
+
+// startListener creates the UDP server, registers its devices and health checks with the
+// plugin, and starts it listening in the background.
+func startListener(p *sdk.Plugin, sensors []jti.SensorDecoder) error {
+	// First, get the pre-loaded server configuration parsed from the plugin
+	// configuration's dynamicRegistration block.
+	serverConfig := config.Get()
+	if serverConfig == nil {
+		return errors.New("failed to load cached UDP server configuration")
+	}
+
+	// Create the device manager used by the server to get, create, and register devices.
+	deviceManager := manager.NewPluginDeviceManager(p)
+
+	// Create the UDP server from the configuration.
+	svr := protocol.NewJtiUDPServer(serverConfig, deviceManager, sensors...)
+
+	// Register the listener device, which pushes the readings of all devices to
+	// Synse as they are received. The SDK only listens for the devices which
+	// exist when the plugin starts.
+	if err := svr.RegisterListener(); err != nil {
+		return err
+	}
 
-		// First, get the pre-loaded server configuration parsed from the plugin
-		// configuration's dynamicRegistration block.
-		serverConfig := config.Get()
-		if serverConfig == nil {
-			return errors.New("failed to load cached UDP server configuration")
+	// Register the devices for the expected inventory, so that they exist even
+	// if their data is never received.
+	if err := svr.RegisterExpected(); err != nil {
+		return err
+	}
+
+	// Register the devices which were discovered before the plugin restarted, so
+	// they do not disappear until their data is received again. The state is only
+	// a cache of the discovered devices, so failing to restore it is not fatal.
+	if err := svr.RestoreState(); err != nil {
+		log.WithError(err).Warning("[jti] failed to restore device state - devices will be registered as data is received")
+	}
+
+	// Surface the state of the listener through the plugin health. The listener
+	// recovers from socket errors itself, so a failed listener is reported as
+	// unhealthy rather than terminating the plugin. The plugin is also reported
+	// as unhealthy if the listener is up, but data has stopped arriving.
+	checks := []health.Check{
+		health.NewPeriodicHealthCheck("jti udp listener", listenerHealthInterval, svr.Health),
+		health.NewPeriodicHealthCheck("jti data freshness", listenerHealthInterval, svr.Freshness),
+	}
+	if len(serverConfig.Expected) != 0 {
+		checks = append(checks, health.NewPeriodicHealthCheck("jti expected inventory", listenerHealthInterval, svr.Inventory))
+	}
+	if err := p.RegisterHealthChecks(checks...); err != nil {
+		return err
+	}
+
+	listenersMu.Lock()
+	listeners = append(listeners, svr)
+	listenersMu.Unlock()
+
+	log.Info("[jti] starting UDP server listen")
+	go func() {
+		if err := svr.Listen(context.Background()); err != nil {
+			log.WithError(err).Error("[jti] failed UDP server listen")
+			return
 		}
+		log.Info("[jti] finished UDP server listen")
+	}()
 
-		// Create the device manager used by the server to get, create, and register devices.
-		deviceManager := manager.NewPluginDeviceManager(p)
+	return nil
+}
 
-		// Create the UDP server from the configuration.
-		svr := protocol.NewJtiUDPServer(serverConfig, deviceManager)
+// StopListeners is a plugin post-run action which stops the UDP servers started by
+// RunBackgroundListener. All of the servers are stopped before waiting for any of them
+// to finish processing the data they have already received.
+var StopListeners = sdk.PluginAction{
+	Name: "stop JTI listeners",
+	Action: func(p *sdk.Plugin) error {
+		listenersMu.Lock()
+		servers := listeners
+		listeners = nil
+		listenersMu.Unlock()
 
-		log.Info("[jti] starting UDP server listen")
-		go func() {
-			if err := svr.Listen(); err != nil {
-				log.WithError(err).Error("[jti] failed UDP server listen")
-				panic(err)
-			}
-			log.Info("[jti] finished UDP server listen")
-		}()
+		for _, svr := range servers {
+			svr.Stop()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
 
+		log.WithField("listeners", len(servers)).Info("[jti] shutting down UDP server listen")
+		for _, svr := range servers {
+			if err := svr.Shutdown(ctx); err != nil {
+				log.WithError(err).Error("[jti] failed to shut down UDP server listen")
+				return err
+			}
+		}
 		return nil
 	},
 }
@@ -1,10 +1,14 @@
 package pkg
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol"
 	"github.com/vapor-ware/synse-sdk/sdk"
 )
 
@@ -14,3 +18,32 @@ func TestRunBackgroundListenerAction_ErrNoConfig(t *testing.T) {
 	err := RunBackgroundListener.Action(&sdk.Plugin{})
 	assert.Error(t, err)
 }
+
+func TestStopListenersAction(t *testing.T) {
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		svr := protocol.NewJtiUDPServer(&config.ServerConfig{Address: "udp4://127.0.0.1:0"}, manager.NewStubDeviceManager(false))
+		listeners = append(listeners, svr)
+		go func() {
+			errs <- svr.Listen(context.Background())
+		}()
+	}
+
+	err := StopListeners.Action(&sdk.Plugin{})
+	assert.NoError(t, err)
+	assert.Empty(t, listeners)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for listener to stop")
+		}
+	}
+}
+
+func TestStopListenersAction_NoListeners(t *testing.T) {
+	err := StopListeners.Action(&sdk.Plugin{})
+	assert.NoError(t, err)
+}
@@ -2,17 +2,35 @@ package config
 
 import (
 	"errors"
+	"runtime"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 )
 
 // Errors related to loading and parsing data source configurations.
 var (
-	ErrNoAddress = errors.New("data source configuration does not define required 'address' value")
+	ErrNoAddress         = errors.New("data source configuration does not define required 'address' value")
+	ErrInvalidFlapWindow = errors.New("flap detection window must be a positive duration")
+	ErrInvalidWorkers    = errors.New("number of decode workers must not be negative")
+	ErrInvalidQueueSize  = errors.New("ingest queue size must not be negative")
+	ErrInvalidSockets    = errors.New("number of listener sockets must not be negative")
+	ErrUnsupportedSocket = errors.New("more than one listener socket is only supported on Linux")
+	ErrInvalidRecvBuffer = errors.New("socket receive buffer size must not be negative")
+	ErrInvalidHealth     = errors.New("data freshness window must be a positive duration")
+	ErrNoExpectedSystem  = errors.New("expected router does not define required 'system_id' value")
+	ErrNoExpectedIfName  = errors.New("expected interface does not define required 'name' value")
+	ErrInvalidState      = errors.New("state save interval must be a positive duration")
+	ErrNoIDComponents    = errors.New("device ID components must not be empty")
+	ErrInvalidFailover   = errors.New("redundant stream failover timeout must be a positive duration")
 )
 
 var serverConfig *ServerConfig
 
+// multipleSockets is whether more than one listener socket may be used on the current
+// platform. This requires SO_REUSEPORT, which is only used on Linux.
+var multipleSockets = runtime.GOOS == "linux"
+
 // ServerConfig is the configuration for the UDP server. This is loaded in from
 // the plugin dynamic registration block.
 type ServerConfig struct {
@@ -25,6 +43,246 @@ type ServerConfig struct {
 	// Contexts allow users to define arbitrary context key-value pairs to be globally
 	// applied to the devices for a plugin instance.
 	Context map[string]string `yaml:"context,omitempty"`
+
+	// Optics configures how optics diagnostics are evaluated against the thresholds
+	// reported by the optic.
+	Optics OpticsConfig `yaml:"optics,omitempty"`
+
+	// Flaps configures link flap detection for interfaces.
+	Flaps FlapConfig `yaml:"flaps,omitempty"`
+
+	// Filters define which of the received data should be dropped before any
+	// devices are created for it.
+	Filters FilterConfig `yaml:"filters,omitempty"`
+
+	// Sources configures which sources telemetry is accepted from, as well as any
+	// additional context to apply to the devices of specific sources.
+	Sources SourceConfig `yaml:"sources,omitempty"`
+
+	// StreamTags enables tagging devices with the components parsed from the
+	// system ID and sensor name of the stream they were received on (e.g. the
+	// hostname and routing engine). These components are always added to the
+	// device context.
+	StreamTags bool `yaml:"stream_tags,omitempty" mapstructure:"stream_tags"`
+
+	// Workers is the number of workers which decode received packets. Packets are
+	// sharded across workers by system ID, so the packets from a single device are
+	// always processed in order. If unspecified, DefaultWorkers is used.
+	Workers int `yaml:"workers,omitempty"`
+
+	// QueueSize is the number of received packets which may be queued for each
+	// worker. Packets received while a worker's queue is full are dropped. If
+	// unspecified, DefaultQueueSize is used.
+	QueueSize int `yaml:"queue_size,omitempty" mapstructure:"queue_size"`
+
+	// Sockets is the number of sockets which listen on the address, each with its
+	// own reader. If more than one socket is used, the sockets are bound with
+	// SO_REUSEPORT so the kernel distributes packets across them. This is only
+	// supported on Linux; on other platforms, more than one socket is rejected when
+	// the configuration is loaded. If unspecified, a single socket is used.
+	Sockets int `yaml:"sockets,omitempty"`
+
+	// ReceiveBuffer is the size, in bytes, of the receive buffer for each socket.
+	// The kernel may limit the size (e.g. by net.core.rmem_max on Linux). If
+	// unspecified, the operating system default is used.
+	ReceiveBuffer int `yaml:"receive_buffer,omitempty" mapstructure:"receive_buffer"`
+
+	// Health configures the health check for the freshness of received data.
+	Health HealthConfig `yaml:"health,omitempty"`
+
+	// Expected is the inventory of routers, and their interfaces, which are expected
+	// to stream data. Devices for the expected inventory are registered on startup,
+	// rather than when their data is first received.
+	Expected []ExpectedRouter `yaml:"expected,omitempty"`
+
+	// State configures persisting the discovered devices to a local file, so that
+	// they can be registered again when the plugin restarts.
+	State StateConfig `yaml:"state,omitempty"`
+
+	// DeviceIDs configures how the IDs of devices are generated.
+	DeviceIDs DeviceIDConfig `yaml:"device_ids,omitempty" mapstructure:"device_ids"`
+
+	// Redundancy configures the de-duplication of redundant streams, e.g. from both
+	// routing engines of a router, which export the same data.
+	Redundancy RedundancyConfig `yaml:"redundancy,omitempty"`
+}
+
+// RedundancyConfig is the configuration for de-duplicating redundant streams.
+//
+// Streams are redundant if they export the same sensor for the same hostname, e.g.
+// the streams of both routing engines of a router, or the same stream forwarded by
+// more than one collector. For each sample of a sensor, only the data from a single
+// active source is used.
+type RedundancyConfig struct {
+
+	// Enabled enables the de-duplication of redundant streams.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Failover is the duration for which no new data may be received from the
+	// active source of a sample before another source takes over. If unspecified,
+	// DefaultFailover is used.
+	Failover time.Duration `yaml:"failover,omitempty"`
+}
+
+// DeviceIDConfig is the configuration for generating device IDs.
+type DeviceIDConfig struct {
+
+	// Legacy generates device IDs as previous versions of the plugin did, from the
+	// concatenated values of each device's ID components. These IDs may collide, so
+	// this should only be enabled to preserve the IDs of existing devices.
+	Legacy bool `yaml:"legacy,omitempty"`
+
+	// Components selects, for each device type, the ID components which identify the
+	// devices of that type, e.g. {"interface": ["sys", "if"]}. Devices of types which
+	// are not listed are identified by all of their ID components. Devices whose
+	// selected components are the same are merged into a single device.
+	Components map[string][]string `yaml:"components,omitempty"`
+}
+
+// Defaults for the ingest pipeline, used if not explicitly configured.
+const (
+	DefaultWorkers   = 4
+	DefaultQueueSize = 1024
+)
+
+// OpticsConfig is the configuration for evaluating optics diagnostics.
+type OpticsConfig struct {
+
+	// Hysteresis defines the margins by which a value must return within a threshold
+	// before its status is lowered. This prevents the status of a value which hovers
+	// around a threshold from flapping.
+	Hysteresis HysteresisConfig `yaml:"hysteresis,omitempty"`
+
+	// Types maps optics type codes, as reported by the optic, to the transceiver
+	// type they describe. These take precedence over the plugin's built-in mapping,
+	// allowing unknown codes to be named and built-in names to be corrected.
+	Types map[uint32]OpticsType `yaml:"types,omitempty"`
+}
+
+// OpticsType describes the type of an optical transceiver.
+type OpticsType struct {
+
+	// FormFactor is the physical form factor of the transceiver, e.g. "QSFP28".
+	FormFactor string `yaml:"form_factor,omitempty" mapstructure:"form_factor"`
+
+	// Media is the media type of the transceiver, e.g. "LR4".
+	Media string `yaml:"media,omitempty"`
+}
+
+// HysteresisConfig defines the hysteresis margins used when evaluating optics values
+// against their thresholds, in the unit of the value being evaluated.
+type HysteresisConfig struct {
+
+	// Temperature is the margin for temperature values, in degrees Celsius.
+	Temperature float64 `yaml:"temperature,omitempty"`
+
+	// Power is the margin for laser output and receiver power values, in dBm.
+	Power float64 `yaml:"power,omitempty"`
+
+	// Current is the margin for laser bias current values, in mA.
+	Current float64 `yaml:"current,omitempty"`
+}
+
+// DefaultHysteresis is the hysteresis configuration used for any margins which are
+// not explicitly configured.
+var DefaultHysteresis = HysteresisConfig{
+	Temperature: 1,
+	Power:       0.5,
+	Current:     0.5,
+}
+
+// FlapConfig is the configuration for detecting interface link flaps.
+type FlapConfig struct {
+
+	// Windows are the sliding windows over which interface transitions are counted.
+	// An interface is considered to be flapping if the number of transitions within
+	// any of the windows exceeds the threshold for that window.
+	Windows []FlapWindow `yaml:"windows,omitempty"`
+}
+
+// FlapWindow is a sliding window over which interface transitions are counted.
+type FlapWindow struct {
+
+	// Window is the duration of the sliding window, e.g. "5m".
+	Window time.Duration `yaml:"window,omitempty"`
+
+	// Threshold is the number of transitions within the window above which an
+	// interface is considered to be flapping.
+	Threshold int `yaml:"threshold,omitempty"`
+}
+
+// HealthConfig is the configuration for the health check which reports whether data
+// is still being received.
+type HealthConfig struct {
+
+	// Window is the duration within which a valid packet must have been received for
+	// the data to be considered fresh. If unspecified, DefaultHealthWindow is used.
+	Window time.Duration `yaml:"window,omitempty"`
+
+	// SystemIDs are the system IDs of the devices which are expected to stream data.
+	// If set, a valid packet must have been received from each of them within the
+	// window. Otherwise, a valid packet from any device is sufficient.
+	SystemIDs []string `yaml:"system_ids,omitempty" mapstructure:"system_ids"`
+}
+
+// ExpectedRouter is a router which is expected to stream data.
+type ExpectedRouter struct {
+
+	// SystemID is the system ID which the router streams data with, e.g.
+	// "router1:10.1.1.1".
+	SystemID string `yaml:"system_id,omitempty" mapstructure:"system_id"`
+
+	// Interfaces are the interfaces of the router which are expected to be reported.
+	Interfaces []ExpectedInterface `yaml:"interfaces,omitempty"`
+}
+
+// ExpectedInterface is an interface which is expected to be reported by a router.
+//
+// Interfaces are identified by the component which reports them as well as their
+// name, so the component IDs must match those the router streams the interface with
+// in order for the expected interface to be matched with its data.
+type ExpectedInterface struct {
+
+	// Name is the name of the interface, e.g. "xe-0/0/0".
+	Name string `yaml:"name,omitempty"`
+
+	// ComponentID is the ID of the component which reports the interface.
+	ComponentID uint32 `yaml:"component_id,omitempty" mapstructure:"component_id"`
+
+	// SubComponentID is the ID of the sub-component which reports the interface.
+	SubComponentID uint32 `yaml:"subcomponent_id,omitempty" mapstructure:"subcomponent_id"`
+}
+
+// StateConfig is the configuration for persisting the discovered devices.
+type StateConfig struct {
+
+	// Path is the path of the file which the state is persisted to. If unspecified,
+	// the state is not persisted.
+	Path string `yaml:"path,omitempty"`
+
+	// Interval is the interval at which the state is saved. The state is also saved
+	// when the plugin terminates. If unspecified, DefaultStateInterval is used.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// Readings enables persisting the last readings of each device along with the
+	// device, so that the devices report their last readings after a restart until
+	// new data is received for them.
+	Readings bool `yaml:"readings,omitempty"`
+}
+
+// DefaultStateInterval is the state save interval used if none is configured.
+const DefaultStateInterval = 1 * time.Minute
+
+// DefaultFailover is the redundant stream failover timeout used if none is configured.
+const DefaultFailover = 30 * time.Second
+
+// DefaultHealthWindow is the data freshness window used if none is configured.
+const DefaultHealthWindow = 5 * time.Minute
+
+// DefaultFlapWindows are the flap detection windows used if none are configured.
+var DefaultFlapWindows = []FlapWindow{
+	{Window: 5 * time.Minute, Threshold: 4},
+	{Window: 1 * time.Hour, Threshold: 10},
 }
 
 // Load the configuration for the plugin's UDP server which will listen for the
@@ -33,14 +291,105 @@ type ServerConfig struct {
 // This also performs basic validation of the data being loaded. It ensures
 // that required fields are not empty.
 func Load(raw map[string]interface{}) (*ServerConfig, error) {
-	var cfg ServerConfig
-	if err := mapstructure.Decode(raw, &cfg); err != nil {
+	cfg := ServerConfig{
+		Optics: OpticsConfig{
+			Hysteresis: DefaultHysteresis,
+		},
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     &cfg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
 		return nil, err
 	}
 
 	if cfg.Address == "" {
 		return nil, ErrNoAddress
 	}
+
+	if len(cfg.Flaps.Windows) == 0 {
+		cfg.Flaps.Windows = DefaultFlapWindows
+	}
+	for _, w := range cfg.Flaps.Windows {
+		if w.Window <= 0 {
+			return nil, ErrInvalidFlapWindow
+		}
+	}
+
+	if cfg.Workers < 0 {
+		return nil, ErrInvalidWorkers
+	}
+	if cfg.Workers == 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.QueueSize < 0 {
+		return nil, ErrInvalidQueueSize
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+
+	if cfg.Sockets < 0 {
+		return nil, ErrInvalidSockets
+	}
+	if cfg.Sockets == 0 {
+		cfg.Sockets = 1
+	}
+	if cfg.Sockets > 1 && !multipleSockets {
+		return nil, ErrUnsupportedSocket
+	}
+	if cfg.ReceiveBuffer < 0 {
+		return nil, ErrInvalidRecvBuffer
+	}
+
+	if cfg.Health.Window < 0 {
+		return nil, ErrInvalidHealth
+	}
+	if cfg.Health.Window == 0 {
+		cfg.Health.Window = DefaultHealthWindow
+	}
+
+	if cfg.State.Interval < 0 {
+		return nil, ErrInvalidState
+	}
+	if cfg.State.Interval == 0 {
+		cfg.State.Interval = DefaultStateInterval
+	}
+
+	if cfg.Redundancy.Failover < 0 {
+		return nil, ErrInvalidFailover
+	}
+	if cfg.Redundancy.Failover == 0 {
+		cfg.Redundancy.Failover = DefaultFailover
+	}
+
+	for _, components := range cfg.DeviceIDs.Components {
+		if len(components) == 0 {
+			return nil, ErrNoIDComponents
+		}
+	}
+
+	for _, router := range cfg.Expected {
+		if router.SystemID == "" {
+			return nil, ErrNoExpectedSystem
+		}
+		for _, iface := range router.Interfaces {
+			if iface.Name == "" {
+				return nil, ErrNoExpectedIfName
+			}
+		}
+	}
+
+	if err := cfg.Filters.Compile(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Sources.Compile(); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -69,3 +70,385 @@ func TestGet2(t *testing.T) {
 	c := Get()
 	assert.Equal(t, cfg, c)
 }
+
+func TestLoad_DefaultHysteresis(t *testing.T) {
+	raw := map[string]interface{}{
+		"address": "localhost",
+	}
+
+	cfg, err := Load(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultHysteresis, cfg.Optics.Hysteresis)
+}
+
+func TestLoad_Hysteresis(t *testing.T) {
+	raw := map[string]interface{}{
+		"address": "localhost",
+		"optics": map[string]interface{}{
+			"hysteresis": map[string]interface{}{
+				"temperature": 2.5,
+				"current":     0,
+			},
+		},
+	}
+
+	cfg, err := Load(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, HysteresisConfig{
+		Temperature: 2.5,
+		Power:       DefaultHysteresis.Power,
+		Current:     0,
+	}, cfg.Optics.Hysteresis)
+}
+
+func TestLoad_DefaultFlapWindows(t *testing.T) {
+	raw := map[string]interface{}{
+		"address": "localhost",
+	}
+
+	cfg, err := Load(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultFlapWindows, cfg.Flaps.Windows)
+}
+
+func TestLoad_FlapWindows(t *testing.T) {
+	raw := map[string]interface{}{
+		"address": "localhost",
+		"flaps": map[string]interface{}{
+			"windows": []interface{}{
+				map[string]interface{}{"window": "10m", "threshold": 3},
+			},
+		},
+	}
+
+	cfg, err := Load(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, []FlapWindow{{Window: 10 * time.Minute, Threshold: 3}}, cfg.Flaps.Windows)
+}
+
+func TestLoad_ErrInvalidFlapWindow(t *testing.T) {
+	raw := map[string]interface{}{
+		"address": "localhost",
+		"flaps": map[string]interface{}{
+			"windows": []interface{}{
+				map[string]interface{}{"threshold": 3},
+			},
+		},
+	}
+
+	cfg, err := Load(raw)
+	assert.Error(t, err)
+	assert.Equal(t, ErrInvalidFlapWindow, err)
+	assert.Nil(t, cfg)
+}
+
+func TestLoad_OpticsTypes(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"optics": map[string]interface{}{
+			"types": map[interface{}]interface{}{
+				17: map[string]interface{}{
+					"form_factor": "QSFP28",
+					"media":       "LR4",
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint32]OpticsType{
+		17: {FormFactor: "QSFP28", Media: "LR4"},
+	}, cfg.Optics.Types)
+}
+
+func TestLoad_DefaultPipeline(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultWorkers, cfg.Workers)
+	assert.Equal(t, DefaultQueueSize, cfg.QueueSize)
+}
+
+func TestLoad_Pipeline(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address":    "localhost",
+		"workers":    8,
+		"queue_size": 64,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, cfg.Workers)
+	assert.Equal(t, 64, cfg.QueueSize)
+}
+
+func TestLoad_ErrInvalidPipeline(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"workers": -1,
+	})
+	assert.Equal(t, ErrInvalidWorkers, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = Load(map[string]interface{}{
+		"address":    "localhost",
+		"queue_size": -1,
+	})
+	assert.Equal(t, ErrInvalidQueueSize, err)
+	assert.Nil(t, cfg)
+}
+
+func TestLoad_Sockets(t *testing.T) {
+	defer func(supported bool) { multipleSockets = supported }(multipleSockets)
+	multipleSockets = true
+
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cfg.Sockets)
+	assert.Equal(t, 0, cfg.ReceiveBuffer)
+
+	cfg, err = Load(map[string]interface{}{
+		"address":        "localhost",
+		"sockets":        4,
+		"receive_buffer": 8388608,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, cfg.Sockets)
+	assert.Equal(t, 8388608, cfg.ReceiveBuffer)
+}
+
+func TestLoad_ErrInvalidSockets(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"sockets": -1,
+	})
+	assert.Equal(t, ErrInvalidSockets, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = Load(map[string]interface{}{
+		"address":        "localhost",
+		"receive_buffer": -1,
+	})
+	assert.Equal(t, ErrInvalidRecvBuffer, err)
+	assert.Nil(t, cfg)
+}
+
+func TestLoad_ErrUnsupportedSocket(t *testing.T) {
+	defer func(supported bool) { multipleSockets = supported }(multipleSockets)
+	multipleSockets = false
+
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"sockets": 2,
+	})
+	assert.Equal(t, ErrUnsupportedSocket, err)
+	assert.Nil(t, cfg)
+
+	// A single socket is supported on all platforms.
+	cfg, err = Load(map[string]interface{}{
+		"address": "localhost",
+		"sockets": 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cfg.Sockets)
+}
+
+func TestLoad_DefaultHealth(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, HealthConfig{Window: DefaultHealthWindow}, cfg.Health)
+}
+
+func TestLoad_Health(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"health": map[string]interface{}{
+			"window":     "90s",
+			"system_ids": []string{"r1:10.0.0.1", "r2:10.0.0.2"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, HealthConfig{
+		Window:    90 * time.Second,
+		SystemIDs: []string{"r1:10.0.0.1", "r2:10.0.0.2"},
+	}, cfg.Health)
+}
+
+func TestLoad_ErrInvalidHealth(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"health": map[string]interface{}{
+			"window": "-1m",
+		},
+	})
+	assert.Equal(t, ErrInvalidHealth, err)
+	assert.Nil(t, cfg)
+}
+
+func TestLoad_Expected(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"expected": []map[string]interface{}{
+			{
+				"system_id": "r1:10.0.0.1",
+				"interfaces": []map[string]interface{}{
+					{"name": "xe-0/0/0", "component_id": 1, "subcomponent_id": 2},
+					{"name": "xe-0/0/1"},
+				},
+			},
+			{
+				"system_id": "r2:10.0.0.2",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []ExpectedRouter{
+		{
+			SystemID: "r1:10.0.0.1",
+			Interfaces: []ExpectedInterface{
+				{Name: "xe-0/0/0", ComponentID: 1, SubComponentID: 2},
+				{Name: "xe-0/0/1"},
+			},
+		},
+		{
+			SystemID: "r2:10.0.0.2",
+		},
+	}, cfg.Expected)
+}
+
+func TestLoad_ErrInvalidExpected(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"expected": []map[string]interface{}{
+			{"interfaces": []map[string]interface{}{{"name": "xe-0/0/0"}}},
+		},
+	})
+	assert.Equal(t, ErrNoExpectedSystem, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = Load(map[string]interface{}{
+		"address": "localhost",
+		"expected": []map[string]interface{}{
+			{"system_id": "r1:10.0.0.1", "interfaces": []map[string]interface{}{{"component_id": 1}}},
+		},
+	})
+	assert.Equal(t, ErrNoExpectedIfName, err)
+	assert.Nil(t, cfg)
+}
+
+func TestLoad_State(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, StateConfig{Interval: DefaultStateInterval}, cfg.State)
+
+	cfg, err = Load(map[string]interface{}{
+		"address": "localhost",
+		"state": map[string]interface{}{
+			"path":     "/var/lib/jti/state.json",
+			"interval": "30s",
+			"readings": true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, StateConfig{
+		Path:     "/var/lib/jti/state.json",
+		Interval: 30 * time.Second,
+		Readings: true,
+	}, cfg.State)
+}
+
+func TestLoad_ErrInvalidState(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"state": map[string]interface{}{
+			"interval": "-1s",
+		},
+	})
+	assert.Equal(t, ErrInvalidState, err)
+	assert.Nil(t, cfg)
+}
+
+func TestLoad_DeviceIDs(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+	})
+	assert.NoError(t, err)
+	assert.False(t, cfg.DeviceIDs.Legacy)
+
+	cfg, err = Load(map[string]interface{}{
+		"address": "localhost",
+		"device_ids": map[string]interface{}{
+			"legacy": true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, cfg.DeviceIDs.Legacy)
+}
+
+func TestLoad_DeviceIDComponents(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"device_ids": map[string]interface{}{
+			"components": map[string]interface{}{
+				"interface": []string{"sys", "if"},
+				"optic":     []string{"sys", "if"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"interface": {"sys", "if"},
+		"optic":     {"sys", "if"},
+	}, cfg.DeviceIDs.Components)
+}
+
+func TestLoad_ErrNoIDComponents(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"device_ids": map[string]interface{}{
+			"components": map[string]interface{}{
+				"interface": []string{},
+			},
+		},
+	})
+	assert.Equal(t, ErrNoIDComponents, err)
+	assert.Nil(t, cfg)
+}
+
+func TestLoad_DefaultRedundancy(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, RedundancyConfig{Failover: DefaultFailover}, cfg.Redundancy)
+}
+
+func TestLoad_Redundancy(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"redundancy": map[string]interface{}{
+			"enabled":  true,
+			"failover": "2m",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, RedundancyConfig{
+		Enabled:  true,
+		Failover: 2 * time.Minute,
+	}, cfg.Redundancy)
+}
+
+func TestLoad_ErrInvalidFailover(t *testing.T) {
+	cfg, err := Load(map[string]interface{}{
+		"address": "localhost",
+		"redundancy": map[string]interface{}{
+			"failover": "-30s",
+		},
+	})
+	assert.Equal(t, ErrInvalidFailover, err)
+	assert.Nil(t, cfg)
+}
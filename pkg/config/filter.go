@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FilterConfig is the configuration for filtering the data which is received from
+// Juniper devices. Filtered data is dropped before any devices are created for it.
+type FilterConfig struct {
+
+	// SystemID filters messages by the system ID of the sending device.
+	SystemID Filter `yaml:"system_id,omitempty" mapstructure:"system_id"`
+
+	// Interface filters devices by the name of the interface they describe.
+	Interface Filter `yaml:"interface,omitempty"`
+
+	// Sensor filters messages by the name of the sensor which produced them.
+	Sensor Filter `yaml:"sensor,omitempty"`
+
+	// Metric filters device readings by their "metric" context value.
+	Metric Filter `yaml:"metric,omitempty"`
+}
+
+// Compile all of the filters, returning an error if any filter pattern is invalid.
+func (c *FilterConfig) Compile() error {
+	for name, f := range map[string]*Filter{
+		"system_id": &c.SystemID,
+		"interface": &c.Interface,
+		"sensor":    &c.Sensor,
+		"metric":    &c.Metric,
+	} {
+		if err := f.Compile(); err != nil {
+			return fmt.Errorf("invalid %s filter: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Filter defines patterns to include and exclude values by.
+//
+// Patterns are globs, where "*" matches any sequence of characters and "?" matches
+// any single character. Patterns which are wrapped in slashes, e.g. "/^pfh-/", are
+// regular expressions.
+type Filter struct {
+
+	// Include lists the patterns of values to include. If empty, all values are
+	// included unless they are excluded.
+	Include []string `yaml:"include,omitempty"`
+
+	// Exclude lists the patterns of values to exclude. Exclusion takes precedence
+	// over inclusion.
+	Exclude []string `yaml:"exclude,omitempty"`
+
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// Compile the filter's include and exclude patterns. This must be called before
+// the filter is used.
+func (f *Filter) Compile() error {
+	include, err := compilePatterns(f.Include)
+	if err != nil {
+		return err
+	}
+	exclude, err := compilePatterns(f.Exclude)
+	if err != nil {
+		return err
+	}
+	f.include = include
+	f.exclude = exclude
+	return nil
+}
+
+// Allows checks whether the filter allows the given value.
+func (f *Filter) Allows(value string) bool {
+	if len(f.include) > 0 && !matchAny(f.include, value) {
+		return false
+	}
+	return !matchAny(f.exclude, value)
+}
+
+// matchAny checks whether the value matches any of the given expressions.
+func matchAny(exprs []*regexp.Regexp, value string) bool {
+	for _, expr := range exprs {
+		if expr.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePatterns compiles filter patterns into regular expressions.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var exprs []*regexp.Regexp
+	for _, pattern := range patterns {
+		var expr string
+		if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expr = pattern[1 : len(pattern)-1]
+		} else {
+			expr = globToRegexp(pattern)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, re)
+	}
+	return exprs, nil
+}
+
+// globToRegexp converts a glob pattern to an anchored regular expression.
+func globToRegexp(glob string) string {
+	expr := regexp.QuoteMeta(glob)
+	expr = strings.Replace(expr, `\*`, `.*`, -1)
+	expr = strings.Replace(expr, `\?`, `.`, -1)
+	return "^" + expr + "$"
+}
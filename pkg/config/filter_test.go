@@ -0,0 +1,85 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Allows(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		value    string
+		expected bool
+	}{
+		{"no patterns", Filter{}, "et-0/0/0", true},
+		{"include glob match", Filter{Include: []string{"et-*"}}, "et-0/0/0", true},
+		{"include glob no match", Filter{Include: []string{"et-*"}}, "xe-0/0/0", false},
+		{"include single char", Filter{Include: []string{"em?"}}, "em0", true},
+		{"include is anchored", Filter{Include: []string{"em0"}}, "em01", false},
+		{"exclude glob match", Filter{Exclude: []string{"*.local"}}, "lo0.local", false},
+		{"exclude glob no match", Filter{Exclude: []string{"*.local"}}, "lo0.0", true},
+		{"exclude precedence", Filter{Include: []string{"*"}, Exclude: []string{"pfh-*"}}, "pfh-0/0/0", false},
+		{"include regex", Filter{Include: []string{"/^(et|xe)-/"}}, "xe-0/0/0", true},
+		{"include regex unanchored", Filter{Include: []string{"/local/"}}, "lo0.local", true},
+		{"exclude regex", Filter{Exclude: []string{"/^if_in_/"}}, "if_in_runts", false},
+		{"glob special chars", Filter{Include: []string{"et-0/0/0.0"}}, "et-0/0/0x0", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.NoError(t, test.filter.Compile())
+			assert.Equal(t, test.expected, test.filter.Allows(test.value))
+		})
+	}
+}
+
+func TestFilter_Compile_Error(t *testing.T) {
+	f := Filter{Include: []string{"/[a-/"}}
+	assert.Error(t, f.Compile())
+}
+
+func TestFilterConfig_Compile_Error(t *testing.T) {
+	c := FilterConfig{
+		Metric: Filter{Exclude: []string{"/(/"}},
+	}
+	assert.Error(t, c.Compile())
+}
+
+func TestLoad_Filters(t *testing.T) {
+	raw := map[string]interface{}{
+		"address": "localhost",
+		"filters": map[string]interface{}{
+			"system_id": map[string]interface{}{
+				"include": []string{"router-*"},
+			},
+			"interface": map[string]interface{}{
+				"exclude": []string{"*.local", "/^pfh-/"},
+			},
+		},
+	}
+
+	cfg, err := Load(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"router-*"}, cfg.Filters.SystemID.Include)
+	assert.True(t, cfg.Filters.SystemID.Allows("router-1"))
+	assert.False(t, cfg.Filters.SystemID.Allows("lab-1"))
+	assert.False(t, cfg.Filters.Interface.Allows("pfh-0/0/0"))
+	assert.True(t, cfg.Filters.Interface.Allows("et-0/0/0"))
+}
+
+func TestLoad_FiltersError(t *testing.T) {
+	raw := map[string]interface{}{
+		"address": "localhost",
+		"filters": map[string]interface{}{
+			"sensor": map[string]interface{}{
+				"include": []string{"/(/"},
+			},
+		},
+	}
+
+	cfg, err := Load(raw)
+	assert.Error(t, err)
+	assert.Nil(t, cfg)
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SourceConfig is the configuration for the sources which stream telemetry
+// data to the plugin.
+type SourceConfig struct {
+
+	// Allow lists the CIDRs (or individual IP addresses) which telemetry is accepted
+	// from. Packets from any other source are rejected. If empty, packets from all
+	// sources are accepted.
+	Allow []string `yaml:"allow,omitempty"`
+
+	// Context defines additional context which is applied to the devices for
+	// matching sources.
+	Context []SourceContext `yaml:"context,omitempty"`
+
+	allow []*net.IPNet
+}
+
+// SourceContext defines additional device context for a source. A source may be
+// matched by the address it sends from, the system ID it reports, or both. If both
+// are specified, both must match.
+type SourceContext struct {
+
+	// CIDR matches sources whose address is within the CIDR. This may also be
+	// an individual IP address.
+	CIDR string `yaml:"cidr,omitempty"`
+
+	// SystemID matches sources which report the system ID.
+	SystemID string `yaml:"system_id,omitempty" mapstructure:"system_id"`
+
+	// Context is the context to apply to the devices of matching sources.
+	Context map[string]string `yaml:"context,omitempty"`
+
+	network *net.IPNet
+}
+
+// Compile parses the configured CIDRs, returning an error if any are invalid. This
+// must be called before the configuration is used.
+func (c *SourceConfig) Compile() error {
+	c.allow = nil
+	for _, cidr := range c.Allow {
+		network, err := parseCIDR(cidr)
+		if err != nil {
+			return err
+		}
+		c.allow = append(c.allow, network)
+	}
+
+	for i := range c.Context {
+		ctx := &c.Context[i]
+		if ctx.CIDR == "" && ctx.SystemID == "" {
+			return fmt.Errorf("source context must specify a cidr and/or system_id")
+		}
+		if ctx.CIDR != "" {
+			network, err := parseCIDR(ctx.CIDR)
+			if err != nil {
+				return err
+			}
+			ctx.network = network
+		}
+	}
+	return nil
+}
+
+// Allows checks whether packets from the given source IP are accepted.
+func (c *SourceConfig) Allows(ip net.IP) bool {
+	if len(c.allow) == 0 {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, network := range c.allow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContextFor gets the additional device context for a source. If multiple source
+// contexts match, they are merged in the order they are configured, with later
+// entries taking precedence.
+func (c *SourceConfig) ContextFor(ip net.IP, systemID string) map[string]string {
+	var merged map[string]string
+	for _, ctx := range c.Context {
+		if ctx.network != nil && (ip == nil || !ctx.network.Contains(ip)) {
+			continue
+		}
+		if ctx.SystemID != "" && ctx.SystemID != systemID {
+			continue
+		}
+		if merged == nil {
+			merged = make(map[string]string)
+		}
+		for k, v := range ctx.Context {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// parseCIDR parses a CIDR. An individual IP address is also accepted and is parsed
+// as a network containing only that address.
+func parseCIDR(cidr string) (*net.IPNet, error) {
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source address: %q", cidr)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source CIDR: %v", err)
+	}
+	return network, nil
+}
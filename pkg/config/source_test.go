@@ -0,0 +1,110 @@
+package config
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceConfig_Allows(t *testing.T) {
+	c := SourceConfig{
+		Allow: []string{"10.1.0.0/16", "192.168.1.5", "fd00::/8"},
+	}
+	assert.NoError(t, c.Compile())
+
+	assert.True(t, c.Allows(net.ParseIP("10.1.2.3")))
+	assert.True(t, c.Allows(net.ParseIP("192.168.1.5")))
+	assert.True(t, c.Allows(net.ParseIP("fd00::1")))
+	assert.False(t, c.Allows(net.ParseIP("10.2.0.1")))
+	assert.False(t, c.Allows(net.ParseIP("192.168.1.6")))
+	assert.False(t, c.Allows(nil))
+}
+
+func TestSourceConfig_Allows_Empty(t *testing.T) {
+	c := SourceConfig{}
+	assert.NoError(t, c.Compile())
+
+	assert.True(t, c.Allows(net.ParseIP("10.1.2.3")))
+	assert.True(t, c.Allows(nil))
+}
+
+func TestSourceConfig_Compile_Errors(t *testing.T) {
+	tests := []SourceConfig{
+		{Allow: []string{"10.1.0.0/33"}},
+		{Allow: []string{"not-an-ip"}},
+		{Context: []SourceContext{{CIDR: "10.0.0.0/abc"}}},
+		{Context: []SourceContext{{Context: map[string]string{"site": "a"}}}},
+	}
+
+	for _, c := range tests {
+		assert.Error(t, c.Compile())
+	}
+}
+
+func TestSourceConfig_ContextFor(t *testing.T) {
+	c := SourceConfig{
+		Context: []SourceContext{
+			{CIDR: "10.1.0.0/16", Context: map[string]string{"site": "site-1", "role": "edge"}},
+			{CIDR: "10.1.1.0/24", Context: map[string]string{"rack": "r1"}},
+			{SystemID: "router-2", Context: map[string]string{"role": "core"}},
+			{CIDR: "10.2.0.0/16", SystemID: "router-3", Context: map[string]string{"rack": "r3"}},
+		},
+	}
+	assert.NoError(t, c.Compile())
+
+	assert.Equal(t, map[string]string{
+		"site": "site-1",
+		"role": "edge",
+		"rack": "r1",
+	}, c.ContextFor(net.ParseIP("10.1.1.1"), "router-1"))
+
+	assert.Equal(t, map[string]string{
+		"site": "site-1",
+		"role": "core",
+	}, c.ContextFor(net.ParseIP("10.1.2.1"), "router-2"))
+
+	assert.Equal(t, map[string]string{
+		"role": "core",
+	}, c.ContextFor(nil, "router-2"))
+
+	assert.Equal(t, map[string]string{
+		"rack": "r3",
+	}, c.ContextFor(net.ParseIP("10.2.0.1"), "router-3"))
+
+	assert.Nil(t, c.ContextFor(net.ParseIP("10.2.0.1"), "router-4"))
+}
+
+func TestLoad_Sources(t *testing.T) {
+	raw := map[string]interface{}{
+		"address": "localhost",
+		"sources": map[string]interface{}{
+			"allow": []string{"10.0.0.0/8"},
+			"context": []interface{}{
+				map[string]interface{}{
+					"system_id": "router-1",
+					"context":   map[string]string{"rack": "r1"},
+				},
+			},
+		},
+	}
+
+	cfg, err := Load(raw)
+	assert.NoError(t, err)
+	assert.True(t, cfg.Sources.Allows(net.ParseIP("10.1.1.1")))
+	assert.False(t, cfg.Sources.Allows(net.ParseIP("11.1.1.1")))
+	assert.Equal(t, map[string]string{"rack": "r1"}, cfg.Sources.ContextFor(nil, "router-1"))
+}
+
+func TestLoad_SourcesError(t *testing.T) {
+	raw := map[string]interface{}{
+		"address": "localhost",
+		"sources": map[string]interface{}{
+			"allow": []string{"10.0.0.0/40"},
+		},
+	}
+
+	cfg, err := Load(raw)
+	assert.Error(t, err)
+	assert.Nil(t, cfg)
+}
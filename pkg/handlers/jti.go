@@ -21,10 +21,12 @@ import (
 // should have its associated reading data specified in its Data field.
 //
 // This plugin only supports reads since the data is collected from a unidirectional
-// UDP stream.
+// UDP stream. The readings may be polled by Read, and are also pushed by Listen as
+// they are received.
 var JTIDeviceHandler = sdk.DeviceHandler{
-	Name: "jti",
-	Read: jtiDeviceRead,
+	Name:   "jti",
+	Read:   jtiDeviceRead,
+	Listen: jtiDeviceListen,
 }
 
 // jtiDeviceRead implements the read capability for the JTIDeviceHandler.
@@ -37,5 +39,49 @@ func jtiDeviceRead(device *sdk.Device) ([]*output.Reading, error) {
 	if !ok {
 		return nil, fmt.Errorf("error reading device: unexpected reading data type %T", r)
 	}
-	return readings, nil
+	return copyReadings(readings), nil
+}
+
+// copyReadings copies the readings for a device so that they can be returned to the
+// SDK, which merges the device context into the context of each reading it reads.
+//
+// The stored readings share their context maps with the readings of other devices,
+// so they must not be modified. Each copy is given its own context.
+func copyReadings(readings []*output.Reading) []*output.Reading {
+	copies := make([]output.Reading, len(readings))
+	result := make([]*output.Reading, len(readings))
+	for i, r := range readings {
+		copies[i] = *r
+		copies[i].Context = make(map[string]string, len(r.Context))
+		for k, v := range r.Context {
+			copies[i].Context[k] = v
+		}
+		result[i] = &copies[i]
+	}
+	return result
+}
+
+// jtiDeviceListen implements the listen capability for the JTIDeviceHandler.
+//
+// The SDK starts a listener for each device which exists when the plugin starts, but
+// devices are created as their data is received. Instead, the server registers a single
+// listener device before the plugin starts, which forwards the samples for all of the
+// server's devices, including those created later. The listeners for all other devices
+// terminate immediately.
+func jtiDeviceListen(device *sdk.Device, readings chan *sdk.ReadContext) error {
+	samples, ok := device.Data[protocol.SamplesKey].(<-chan *sdk.ReadContext)
+	if !ok {
+		return nil
+	}
+
+	forward(samples, readings)
+	return nil
+}
+
+// forward the samples published for the listener device to the SDK readings channel
+// until the samples channel is closed, when the server is shut down.
+func forward(samples <-chan *sdk.ReadContext, readings chan<- *sdk.ReadContext) {
+	for sample := range samples {
+		readings <- sample
+	}
 }
@@ -51,3 +51,66 @@ func Test_jtiDeviceRead_ErrBadType(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, readings)
 }
+
+func Test_jtiDeviceRead_CopiesReadings(t *testing.T) {
+	ctx := map[string]string{"metric": "if_octets"}
+	stored := []*output.Reading{
+		{Type: "test", Value: 100, Context: ctx},
+	}
+	d := &sdk.Device{
+		Data: map[string]interface{}{
+			protocol.ReadingKey: stored,
+		},
+	}
+
+	readings, err := jtiDeviceRead(d)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 1)
+	assert.Equal(t, ctx, readings[0].Context)
+
+	// Modifying the returned reading does not modify the stored reading or its
+	// shared context.
+	readings[0].WithContext(map[string]string{"site": "test"})
+	readings[0].Value = 200
+	assert.Equal(t, map[string]string{"metric": "if_octets"}, ctx)
+	assert.Equal(t, 100, stored[0].Value)
+}
+
+func Test_forward(t *testing.T) {
+	samples := make(chan *sdk.ReadContext, 2)
+	readings := make(chan *sdk.ReadContext, 2)
+
+	first := sdk.NewReadContext(&sdk.Device{Info: "first"}, nil)
+	second := sdk.NewReadContext(&sdk.Device{Info: "second"}, nil)
+	samples <- first
+	samples <- second
+	close(samples)
+
+	// Samples are forwarded in order, until the samples channel is closed.
+	forward(samples, readings)
+	assert.Same(t, first, <-readings)
+	assert.Same(t, second, <-readings)
+}
+
+func Test_jtiDeviceListen(t *testing.T) {
+	readings := make(chan *sdk.ReadContext, 1)
+
+	// Only the listener device forwards samples. The listeners for all other devices
+	// terminate immediately.
+	assert.NoError(t, jtiDeviceListen(&sdk.Device{Data: map[string]interface{}{}}, readings))
+
+	samples := make(chan *sdk.ReadContext, 1)
+	sample := sdk.NewReadContext(&sdk.Device{Info: "sample"}, nil)
+	samples <- sample
+	close(samples)
+
+	assert.NoError(t, jtiDeviceListen(&sdk.Device{Data: map[string]interface{}{
+		protocol.SamplesKey: (<-chan *sdk.ReadContext)(samples),
+	}}, readings))
+	assert.Same(t, sample, <-readings)
+}
+
+func TestJTIDeviceHandler(t *testing.T) {
+	assert.True(t, JTIDeviceHandler.CanRead())
+	assert.True(t, JTIDeviceHandler.CanListen())
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Namespace is the namespace for all of the plugin's application metrics.
+const Namespace = "jti"
+
+// Application metrics for the plugin. These are registered with the default Prometheus
+// registry, which the SDK exposes when metrics are enabled in the plugin configuration.
+var (
+	// Filtered counts the number of items which were dropped by the configured
+	// filters, labeled by the kind of item filtered (e.g. "interface", "metric").
+	Filtered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "filtered_total",
+		Help:      "The total number of items dropped by the configured filters.",
+	}, []string{"kind"})
+
+	// Rejected counts the number of packets which were rejected because their
+	// source is not allowed.
+	Rejected = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "rejected_packets_total",
+		Help:      "The total number of packets rejected from sources which are not allowed.",
+	})
+
+	// QueueDepth is the number of received packets which are queued for decoding.
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "queue_depth",
+		Help:      "The number of received packets queued for decoding.",
+	})
+
+	// Dropped counts the number of packets which were dropped because the queue
+	// for their worker was full.
+	Dropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "queue_dropped_packets_total",
+		Help:      "The total number of packets dropped because the decode queue was full.",
+	})
+
+	// StageDuration observes the time spent in each stage of processing a packet,
+	// labeled by the stage ("queue", "decode", "devices").
+	StageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "stage_duration_seconds",
+		Help:      "The time spent in each stage of processing a received packet.",
+		Buckets:   []float64{.00005, .0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"stage"})
+
+	// KernelDrops is the number of packets dropped by the kernel for the listener's
+	// sockets, e.g. because the socket receive buffer was full.
+	KernelDrops = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "socket_kernel_drops",
+		Help:      "The number of packets dropped by the kernel for the listener's sockets.",
+	})
+
+	// DeviceErrors counts the number of times a device could not be created or
+	// registered for the data in a received packet.
+	DeviceErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "device_errors_total",
+		Help:      "The total number of times a device failed to be created or registered.",
+	})
+
+	// InventoryMissing is the number of devices of the expected inventory which have
+	// not had any data received for them, labeled by device type.
+	InventoryMissing = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "inventory_missing",
+		Help:      "The number of expected devices which have not had any data received for them.",
+	}, []string{"type"})
+
+	// ListenerRestarts counts the number of times the listener failed and was
+	// rebound to its address.
+	ListenerRestarts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "listener_restarts_total",
+		Help:      "The total number of times the listener failed and was restarted.",
+	})
+
+	// RedundantSamples counts the number of samples dropped from redundant streams,
+	// labeled by the reason the sample was dropped: "duplicate" if it is not newer
+	// than the data already received from its source, or "standby" if it is not from
+	// the active source of the sample.
+	RedundantSamples = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "redundant_samples_total",
+		Help:      "The total number of samples dropped from redundant streams.",
+	}, []string{"reason"})
+
+	// Switchovers counts the number of times the active source of a sample changed,
+	// labeled by the hostname and sensor path of the sample.
+	Switchovers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "switchovers_total",
+		Help:      "The total number of times the active source of a sample changed.",
+	}, []string{"hostname", "sensor"})
+
+	// ActiveSources is the number of samples which each source is the active source
+	// of, labeled by the hostname and sensor path of the samples and the source.
+	ActiveSources = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "active_sources",
+		Help:      "The number of samples which a source is the active source of.",
+	}, []string{"hostname", "sensor", "source"})
+
+	// ListenDropped counts the number of samples which were dropped because the
+	// queue for the device listener was full.
+	ListenDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "listen_dropped_samples_total",
+		Help:      "The total number of samples dropped because the listen queue was full.",
+	})
+)
@@ -3,7 +3,9 @@ package pkg
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"sort"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
@@ -60,34 +62,107 @@ func LoadDynamicConfig(data map[string]interface{}) ([]*sdk.Device, error) {
 // Since all devices are created dynamically at runtime, we have some guarantees of
 // what fields exist in the data field and how they are structured. The runtime device
 // loader will always put all fields pertaining to the ID of the plugin into an map
-// under the "id" key.
+// under the "id" key, and the device type under the "type" key.
+//
+// The identifier is the device type followed by each of the ID components, qualified
+// by its key and escaped, e.g. "interface:cid=0&if=xe-0%2F0%2F0&scid=0&sys=router1", so
+// the identifiers of devices with different ID components can not collide. If legacy
+// device IDs are configured, the identifier is the concatenation of the ID component
+// values, as generated by previous versions of the plugin, so existing device IDs are
+// preserved.
+//
+// The SDK does not allow an error to be returned here, so if the device data is not
+// valid, the error is logged and an empty identifier is returned. Such devices are
+// rejected when they are added to the plugin by ValidateDeviceData.
 func DeviceIdentifier(data map[string]interface{}) string {
-	var identifier string
-
-	idComponents, exists := data["id"]
-	if !exists {
-		panic("device does not contain the expected ID info in its data")
+	identifier, err := deviceIdentifier(data, legacyDeviceIDs())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err":  err,
+			"data": data,
+		}).Error("[jti] failed to construct device identifier")
+		return ""
 	}
 
-	components, ok := idComponents.(map[string]string)
-	if !ok {
-		panic("device ID info is not a map of string:string")
+	log.WithField("identifier", identifier).Debug("[jti] constructed device identifier")
+	return identifier
+}
+
+// ValidateDeviceData is the custom device data validator for the JTI plugin. It checks
+// that the device identifier can be constructed from the device data.
+func ValidateDeviceData(data map[string]interface{}) error {
+	_, err := deviceIdentifier(data, legacyDeviceIDs())
+	return err
+}
+
+// legacyDeviceIDs checks whether legacy device IDs are configured.
+func legacyDeviceIDs() bool {
+	cfg := config.Get()
+	return cfg != nil && cfg.DeviceIDs.Legacy
+}
+
+// deviceIdentifier constructs the identifier for a device from its data, returning an
+// error if the data does not contain valid ID components.
+func deviceIdentifier(data map[string]interface{}, legacy bool) (string, error) {
+	components, err := idComponents(data)
+	if err != nil {
+		return "", err
 	}
 
 	// To ensure that we get the same identifier reliably, we want to make sure
 	// we append the components reliably, so we will sort the keys.
-	var keys []string
+	keys := make([]string, 0, len(components))
 	for k := range components {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 
-	for _, key := range keys {
-		// Instead of implementing our own type checking and casting, just
-		// use Sprint. Note that this may be meaningless for complex types.
-		identifier += fmt.Sprint(components[key])
+	var b strings.Builder
+	if legacy {
+		for _, key := range keys {
+			b.WriteString(components[key])
+		}
+		return b.String(), nil
 	}
 
-	log.WithField("identifier", identifier).Debug("[jti] constructed device identifier")
-	return identifier
+	typ, _ := data["type"].(string)
+	b.WriteString(url.QueryEscape(typ))
+	b.WriteByte(':')
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(key))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(components[key]))
+	}
+	return b.String(), nil
+}
+
+// idComponents gets the ID components from device data. The components are usually a map
+// of string:string, as built by the runtime device loader, but devices which are defined in
+// configuration may have components of other scalar types, which are formatted as strings.
+func idComponents(data map[string]interface{}) (map[string]string, error) {
+	raw, exists := data["id"]
+	if !exists {
+		return nil, errors.New("device does not contain the expected ID info in its data")
+	}
+
+	switch components := raw.(type) {
+	case map[string]string:
+		return components, nil
+	case map[string]interface{}:
+		converted := make(map[string]string, len(components))
+		for k, v := range components {
+			switch v.(type) {
+			case string, bool, int, int64, uint64, float64:
+				converted[k] = fmt.Sprint(v)
+			default:
+				return nil, fmt.Errorf("device ID component %q has unsupported type %T", k, v)
+			}
+		}
+		return converted, nil
+	default:
+		return nil, fmt.Errorf("device ID info is not a map of string:string: %T", raw)
+	}
 }
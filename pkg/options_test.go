@@ -63,28 +63,106 @@ func TestDeviceIdentifier(t *testing.T) {
 			"foo":   "bar",
 			"index": "1",
 		},
+		"type": "interface",
 	})
 
-	assert.Equal(t, "bar1", id)
+	assert.Equal(t, "interface:foo=bar&index=1&test=", id)
 }
 
-func TestDeviceIdentifier_NoID(t *testing.T) {
-	assert.Panics(t, func() {
-		DeviceIdentifier(map[string]interface{}{
+func TestDeviceIdentifier_Escaped(t *testing.T) {
+	id := DeviceIdentifier(map[string]interface{}{
+		"id": map[string]string{
+			"sys": "router1:10.1.1.1",
+			"if":  "xe-0/0/0",
+			"x":   "a&b=c",
+		},
+		"type": "oc interface",
+	})
+
+	assert.Equal(t, "oc+interface:if=xe-0%2F0%2F0&sys=router1%3A10.1.1.1&x=a%26b%3Dc", id)
+}
+
+func TestDeviceIdentifier_NoCollision(t *testing.T) {
+	id1 := DeviceIdentifier(map[string]interface{}{
+		"id":   map[string]string{"if": "0", "sys": "r1"},
+		"type": "interface",
+	})
+	id2 := DeviceIdentifier(map[string]interface{}{
+		"id":   map[string]string{"if": "0r", "sys": "1"},
+		"type": "interface",
+	})
+	assert.NotEqual(t, id1, id2)
+}
+
+func TestDeviceIdentifier_Legacy(t *testing.T) {
+	config.Set(&config.ServerConfig{DeviceIDs: config.DeviceIDConfig{Legacy: true}})
+	defer config.Set(nil)
+
+	id := DeviceIdentifier(map[string]interface{}{
+		"id": map[string]string{
+			"test":  "",
 			"foo":   "bar",
 			"index": "1",
-		})
+		},
+		"type": "interface",
 	})
+	assert.Equal(t, "bar1", id)
+
+	// Legacy IDs may collide.
+	id1 := DeviceIdentifier(map[string]interface{}{"id": map[string]string{"if": "0", "sys": "r1"}})
+	id2 := DeviceIdentifier(map[string]interface{}{"id": map[string]string{"if": "0r", "sys": "1"}})
+	assert.Equal(t, id1, id2)
 }
 
-func TestDeviceIdentifier_BadType(t *testing.T) {
-	assert.Panics(t, func() {
-		DeviceIdentifier(map[string]interface{}{
-			"id": map[string]int{
-				"test":  1,
-				"foo":   2,
-				"index": 3,
-			},
-		})
+func TestDeviceIdentifier_ConfiguredComponents(t *testing.T) {
+	id := DeviceIdentifier(map[string]interface{}{
+		"id": map[string]interface{}{
+			"sys":  "router1",
+			"port": 1,
+		},
+		"type": "interface",
 	})
+
+	assert.Equal(t, "interface:port=1&sys=router1", id)
+}
+
+func TestDeviceIdentifier_NoID(t *testing.T) {
+	data := map[string]interface{}{
+		"foo":   "bar",
+		"index": "1",
+	}
+
+	assert.Equal(t, "", DeviceIdentifier(data))
+	assert.EqualError(t, ValidateDeviceData(data), "device does not contain the expected ID info in its data")
+}
+
+func TestDeviceIdentifier_BadType(t *testing.T) {
+	data := map[string]interface{}{
+		"id": map[string]int{
+			"test":  1,
+			"foo":   2,
+			"index": 3,
+		},
+	}
+
+	assert.Equal(t, "", DeviceIdentifier(data))
+	assert.EqualError(t, ValidateDeviceData(data), "device ID info is not a map of string:string: map[string]int")
+}
+
+func TestDeviceIdentifier_BadComponentType(t *testing.T) {
+	data := map[string]interface{}{
+		"id": map[string]interface{}{
+			"sys": []string{"router1"},
+		},
+	}
+
+	assert.Equal(t, "", DeviceIdentifier(data))
+	assert.EqualError(t, ValidateDeviceData(data), `device ID component "sys" has unsupported type []string`)
+}
+
+func TestValidateDeviceData(t *testing.T) {
+	assert.NoError(t, ValidateDeviceData(map[string]interface{}{
+		"id":   map[string]string{"sys": "router1"},
+		"type": "router",
+	}))
 }
@@ -86,6 +86,18 @@ var PacketsPerSecond = output.Output{
 	},
 }
 
+// Percent is a reading output which describes a percentage of some whole, e.g. the
+// utilization of an interface relative to its speed.
+var Percent = output.Output{
+	Name:      "percent",
+	Type:      "percentage",
+	Precision: 2,
+	Unit: &output.Unit{
+		Name:   "percent",
+		Symbol: "%",
+	},
+}
+
 // TimeTicks is a reading output which describes the passage of time, as measured
 // in "time ticks".
 var TimeTicks = output.Output{
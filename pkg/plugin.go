@@ -4,18 +4,48 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/handlers"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/outputs"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
 	"github.com/vapor-ware/synse-sdk/sdk"
 )
 
+// Option is an option which customizes the plugin created by MakePlugin.
+type Option func(*pluginOptions)
+
+// pluginOptions holds the customizations for the plugin created by MakePlugin.
+type pluginOptions struct {
+	sensorDecoders []jti.SensorDecoder
+}
+
+// WithSensorDecoders is an option which adds decoders for sensors which are not
+// supported by the plugin itself. This allows the plugin to be extended when it is
+// used as a library, without needing to modify it.
+func WithSensorDecoders(decoders ...jti.SensorDecoder) Option {
+	return func(opts *pluginOptions) {
+		opts.sensorDecoders = append(opts.sensorDecoders, decoders...)
+	}
+}
+
 // MakePlugin creates a new instance of an SDK plugin and registers
 // all of the Juniper JTI-specific capabilities with the plugin.
-func MakePlugin() (*sdk.Plugin, error) {
+func MakePlugin(options ...Option) (*sdk.Plugin, error) {
 	log.Debug("[jti] making plugin")
 
+	opts := &pluginOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	// Check any additional sensor decoders now, rather than when the decoder which
+	// uses them is created on plugin run. They are only used by this plugin.
+	if err := jti.ValidateSensorDecoders(opts.sensorDecoders...); err != nil {
+		return nil, err
+	}
+
 	// Create a new plugin instance
 	plugin, err := sdk.NewPlugin(
 		sdk.CustomDynamicDeviceRegistration(LoadDynamicConfig),
 		sdk.CustomDeviceIdentifier(DeviceIdentifier),
+		sdk.CustomDeviceDataValidator(ValidateDeviceData),
 		sdk.PluginConfigRequired(),
 		sdk.DeviceConfigOptional(),
 		sdk.DynamicConfigRequired(),
@@ -25,8 +55,14 @@ func MakePlugin() (*sdk.Plugin, error) {
 	}
 
 	// Register pre-run action(s) with the plugin.
+	listener := runBackgroundListener(opts.sensorDecoders)
 	plugin.RegisterPreRunActions(
-		&RunBackgroundListener,
+		&listener,
+	)
+
+	// Register post-run action(s) with the plugin.
+	plugin.RegisterPostRunActions(
+		&StopListeners,
 	)
 
 	// Register custom output types
@@ -39,6 +75,7 @@ func MakePlugin() (*sdk.Plugin, error) {
 		&outputs.Milliamperes,
 		&outputs.PacketsCounter,
 		&outputs.PacketsPerSecond,
+		&outputs.Percent,
 		&outputs.TimeTicks,
 	)
 	if err != nil {
@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"google.golang.org/protobuf/runtime/protoimpl"
+)
+
+// testSensorExt is a sensor extension which is not supported by the plugin.
+var testSensorExt = &protoimpl.ExtensionInfo{
+	ExtendedType:  (*telemetry_top.JuniperNetworksSensors)(nil),
+	ExtensionType: (*port.Port)(nil),
+	Field:         1001,
+	Name:          "jnpr_plugin_test_ext",
+	Tag:           "bytes,1001,opt,name=jnpr_plugin_test_ext",
+	Filename:      "plugin_test.proto",
+}
+
+func TestWithSensorDecoders(t *testing.T) {
+	d1 := jti.NewSensorDecoder(port.E_JnprInterfaceExt, nil)
+	d2 := jti.NewSensorDecoder(port.E_JnprInterfaceExt, nil)
+
+	opts := &pluginOptions{}
+	WithSensorDecoders(d1)(opts)
+	WithSensorDecoders(d2)(opts)
+	assert.Equal(t, []jti.SensorDecoder{d1, d2}, opts.sensorDecoders)
+}
+
+func TestMakePlugin_ErrSensorDecoder(t *testing.T) {
+	// The port extension is already supported by the plugin.
+	plugin, err := MakePlugin(WithSensorDecoders(jti.NewSensorDecoder(port.E_JnprInterfaceExt, nil)))
+	assert.Error(t, err)
+	assert.Nil(t, plugin)
+}
+
+func TestMakePlugin_SensorDecoders(t *testing.T) {
+	sdk.SetPluginInfo("jti test", "vaporio", "test plugin", "")
+	defer os.Unsetenv(sdk.PluginEnvOverride)
+	assert.NoError(t, os.Setenv(sdk.PluginEnvOverride, "../example/config.yaml"))
+
+	// The sensor decoders are only used by the plugin they are given to, so they are
+	// not registered anywhere which would reject them for a later plugin.
+	decoder := jti.NewSensorDecoder(testSensorExt, nil)
+	plugin, err := MakePlugin(WithSensorDecoders(decoder))
+	assert.NoError(t, err)
+	assert.NotNil(t, plugin)
+	assert.NoError(t, jti.ValidateSensorDecoders(decoder))
+}
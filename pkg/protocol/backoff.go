@@ -0,0 +1,36 @@
+package protocol
+
+import "time"
+
+// Default bounds for the delay between attempts to rebind a listener which failed.
+const (
+	defaultMinBackoff = 1 * time.Second
+	defaultMaxBackoff = 1 * time.Minute
+)
+
+// backoff computes exponentially increasing delays between retries, starting at min
+// and doubling for each retry up to max.
+type backoff struct {
+	min time.Duration
+	max time.Duration
+
+	current time.Duration
+}
+
+// Next gets the delay before the next retry.
+func (b *backoff) Next() time.Duration {
+	if b.current == 0 {
+		b.current = b.min
+	} else {
+		b.current *= 2
+	}
+	if b.current > b.max {
+		b.current = b.max
+	}
+	return b.current
+}
+
+// Reset the backoff so that the next retry uses the minimum delay.
+func (b *backoff) Reset() {
+	b.current = 0
+}
@@ -0,0 +1,21 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	b := backoff{min: time.Second, max: 5 * time.Second}
+
+	assert.Equal(t, 1*time.Second, b.Next())
+	assert.Equal(t, 2*time.Second, b.Next())
+	assert.Equal(t, 4*time.Second, b.Next())
+	assert.Equal(t, 5*time.Second, b.Next())
+	assert.Equal(t, 5*time.Second, b.Next())
+
+	b.Reset()
+	assert.Equal(t, 1*time.Second, b.Next())
+}
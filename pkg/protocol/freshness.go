@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// freshness tracks when valid data was last received, both from any device and from
+// each device by its system ID. A freshness is safe for concurrent use.
+type freshness struct {
+	mu sync.Mutex
+
+	// started is when tracking started. Until data is received, its age is
+	// measured from this time.
+	started time.Time
+	last    time.Time
+	systems map[string]time.Time
+}
+
+// start tracking freshness at the given time.
+func (f *freshness) start(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started = now
+}
+
+// received records that valid data was received from a system at the given time.
+func (f *freshness) received(systemID string, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.last = now
+	if f.systems == nil {
+		f.systems = make(map[string]time.Time)
+	}
+	f.systems[systemID] = now
+}
+
+// check whether valid data was received within the window before now. If systemIDs
+// are given, data must have been received from each of them; otherwise, data from any
+// system is sufficient. An error describing the stale data is returned if it was not.
+func (f *freshness) check(window time.Duration, systemIDs []string, now time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.started.IsZero() {
+		return errors.New("no valid JTI data received: the listener has not started")
+	}
+
+	if len(systemIDs) == 0 {
+		if now.Sub(f.latest(f.last)) > window {
+			return fmt.Errorf("no valid JTI data received within %s", window)
+		}
+		return nil
+	}
+
+	var stale []string
+	for _, id := range systemIDs {
+		if now.Sub(f.latest(f.systems[id])) > window {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) != 0 {
+		sort.Strings(stale)
+		return fmt.Errorf("no valid JTI data received within %s from system(s): %s", window, strings.Join(stale, ", "))
+	}
+	return nil
+}
+
+// latest gets the time from which the age of data last received at t is measured.
+// The caller must hold mu.
+func (f *freshness) latest(t time.Time) time.Time {
+	if t.Before(f.started) {
+		return f.started
+	}
+	return t
+}
@@ -0,0 +1,45 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreshness_NotStarted(t *testing.T) {
+	f := freshness{}
+	f.received("r1", time.Now())
+
+	assert.EqualError(t, f.check(time.Minute, nil, time.Now()), "no valid JTI data received: the listener has not started")
+}
+
+func TestFreshness_Any(t *testing.T) {
+	start := time.Now()
+	f := freshness{}
+	f.start(start)
+
+	// Before any data is received, the age is measured from the start.
+	assert.NoError(t, f.check(time.Minute, nil, start.Add(time.Minute)))
+	assert.EqualError(t, f.check(time.Minute, nil, start.Add(2*time.Minute)), "no valid JTI data received within 1m0s")
+
+	f.received("r1", start.Add(90*time.Second))
+	assert.NoError(t, f.check(time.Minute, nil, start.Add(2*time.Minute)))
+	assert.Error(t, f.check(time.Minute, nil, start.Add(3*time.Minute)))
+}
+
+func TestFreshness_SystemIDs(t *testing.T) {
+	start := time.Now()
+	f := freshness{}
+	f.start(start)
+
+	f.received("r1", start.Add(90*time.Second))
+	f.received("r3", start.Add(90*time.Second))
+
+	// Data from unexpected systems does not make expected systems fresh.
+	assert.EqualError(t,
+		f.check(time.Minute, []string{"r3", "r2", "r1", "r4"}, start.Add(2*time.Minute)),
+		"no valid JTI data received within 1m0s from system(s): r2, r4",
+	)
+	assert.NoError(t, f.check(time.Minute, []string{"r1", "r3"}, start.Add(2*time.Minute)))
+}
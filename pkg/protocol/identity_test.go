@@ -0,0 +1,159 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+)
+
+// makeComponentStream creates the encoded bytes for a TelemetryStream message with port
+// data for the given interfaces, reported by the given component.
+func makeComponentStream(t *testing.T, systemID string, componentID uint32, names ...string) []byte {
+	ts := &telemetry_top.TelemetryStream{}
+	assert.NoError(t, proto.Unmarshal(makeStream(t, systemID, names...), ts))
+	ts.ComponentId = &componentID
+
+	b, err := proto.Marshal(ts)
+	assert.NoError(t, err)
+	return b
+}
+
+func TestJtiUDPServer_identify(t *testing.T) {
+	svr := JtiUDPServer{
+		idComponents: map[string][]string{
+			"interface": {"sys", "if", "sys", "unknown"},
+		},
+	}
+
+	info := &jti.DeviceInfo{
+		Type:         "interface",
+		IDComponents: map[string]string{"sys": "r1", "if": "xe-0/0/0", "cid": "1", "scid": "0"},
+	}
+	svr.identify(info)
+	assert.Equal(t, map[string]string{"sys": "r1", "if": "xe-0/0/0"}, info.IDComponents)
+
+	// Narrowing is idempotent.
+	narrowed := info.IDComponents
+	svr.identify(info)
+	assert.Equal(t, map[string]string{"sys": "r1", "if": "xe-0/0/0"}, info.IDComponents)
+	narrowed["x"] = "y"
+	assert.Equal(t, "y", info.IDComponents["x"], "already narrowed components should not be copied")
+
+	// Types without configured components are identified by all of their components.
+	info = &jti.DeviceInfo{
+		Type:         "optic",
+		IDComponents: map[string]string{"sys": "r1", "if": "xe-0/0/0", "cid": "1", "scid": "0"},
+	}
+	svr.identify(info)
+	assert.Equal(t, map[string]string{"sys": "r1", "if": "xe-0/0/0", "cid": "1", "scid": "0"}, info.IDComponents)
+}
+
+func TestJtiUDPServer_identify_Host(t *testing.T) {
+	svr := JtiUDPServer{
+		idComponents: map[string][]string{
+			"interface": {"host", "if"},
+			"router":    {"sys", "host"},
+		},
+	}
+
+	// The host is derived from the system ID, without its routing engine.
+	info := &jti.DeviceInfo{
+		Type:         "interface",
+		IDComponents: map[string]string{"sys": "re0-r1:10.1.1.1", "if": "xe-0/0/0", "cid": "1", "scid": "0"},
+	}
+	svr.identify(info)
+	assert.Equal(t, map[string]string{"host": "r1", "if": "xe-0/0/0"}, info.IDComponents)
+	svr.identify(info)
+	assert.Equal(t, map[string]string{"host": "r1", "if": "xe-0/0/0"}, info.IDComponents)
+
+	// The host is derived even if all of the other components are selected.
+	info = &jti.DeviceInfo{
+		Type:         "router",
+		IDComponents: map[string]string{"sys": "re1-r1:10.1.1.2"},
+	}
+	svr.identify(info)
+	assert.Equal(t, map[string]string{"sys": "re1-r1:10.1.1.2", "host": "r1"}, info.IDComponents)
+}
+
+func TestJtiUDPServer_process_IDComponents(t *testing.T) {
+	// By default, an interface reported by different components is a different device
+	// for each component.
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, dm)
+	svr.process(&packet{data: makeComponentStream(t, "r1", 1, "xe-0/0/0")})
+	svr.process(&packet{data: makeComponentStream(t, "r1", 2, "xe-0/0/0")})
+	assert.Len(t, dm.devices, 2)
+
+	// If interfaces are only identified by their system and name, they are merged
+	// into a single device, which has the readings of the latest data.
+	dm = newIDDeviceManager()
+	svr = NewJtiUDPServer(&config.ServerConfig{
+		Address: "localhost",
+		DeviceIDs: config.DeviceIDConfig{
+			Components: map[string][]string{"interface": {"sys", "if"}},
+		},
+	}, dm)
+	svr.process(&packet{data: makeComponentStream(t, "r1", 1, "xe-0/0/0")})
+	svr.process(&packet{data: makeComponentStream(t, "r1", 2, "xe-0/0/0")})
+	if assert.Len(t, dm.devices, 1) {
+		for _, device := range dm.devices {
+			assert.Equal(t, map[string]interface{}{
+				"id":   map[string]string{"sys": "r1", "if": "xe-0/0/0"},
+				"type": "interface",
+			}, map[string]interface{}{
+				"id":   device.Data["id"],
+				"type": device.Data["type"],
+			})
+			assert.NotEmpty(t, device.Data[ReadingKey])
+		}
+	}
+	assert.Len(t, svr.registered, 1)
+}
+
+func TestJtiUDPServer_process_HostIDComponent(t *testing.T) {
+	// If interfaces are identified by their host and name, the data streamed by each
+	// routing engine of a router is reported on the same device.
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{
+		Address: "localhost",
+		DeviceIDs: config.DeviceIDConfig{
+			Components: map[string][]string{"interface": {"host", "if"}},
+		},
+	}, dm)
+	svr.process(&packet{data: makeComponentStream(t, "re0-r1:10.1.1.1", 1, "xe-0/0/0")})
+	svr.process(&packet{data: makeComponentStream(t, "re1-r1:10.1.1.2", 2, "xe-0/0/0")})
+	if assert.Len(t, dm.devices, 1) {
+		for _, device := range dm.devices {
+			assert.Equal(t, map[string]string{"host": "r1", "if": "xe-0/0/0"}, device.Data["id"])
+		}
+	}
+
+	// Different routers are still different devices.
+	svr.process(&packet{data: makeComponentStream(t, "re0-r2:10.1.2.1", 1, "xe-0/0/0")})
+	assert.Len(t, dm.devices, 2)
+}
+
+func TestJtiUDPServer_RegisterExpected_IDComponents(t *testing.T) {
+	// Expected interfaces are narrowed the same way as their data, so a router's
+	// interface is matched regardless of which component reports it.
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{
+		Address: "localhost",
+		DeviceIDs: config.DeviceIDConfig{
+			Components: map[string][]string{"interface": {"sys", "if"}},
+		},
+		Expected: []config.ExpectedRouter{
+			{SystemID: "r1", Interfaces: []config.ExpectedInterface{{Name: "xe-0/0/0"}}},
+		},
+	}, dm)
+	assert.NoError(t, svr.RegisterExpected())
+	assert.Len(t, dm.devices, 2)
+
+	svr.process(&packet{data: makeComponentStream(t, "r1", 3, "xe-0/0/0"), systemID: "r1"})
+	assert.Len(t, dm.devices, 2)
+	assert.Empty(t, svr.MissingInventory())
+}
@@ -0,0 +1,210 @@
+package protocol
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+// Values of the data status reading for devices of the expected inventory.
+const (
+	dataStatusNoData = "no data"
+	dataStatusOk     = "ok"
+)
+
+// maxMissingListed is the maximum number of missing devices which are listed by the
+// expected inventory health check.
+const maxMissingListed = 10
+
+// expectedDevice is a device of the expected inventory.
+type expectedDevice struct {
+	typ      string
+	info     string
+	received bool
+}
+
+// inventory tracks which devices of the expected inventory have had data received
+// for them. An inventory is safe for concurrent use.
+type inventory struct {
+	mu sync.Mutex
+
+	// devices are the expected devices, keyed by device ID.
+	devices map[string]*expectedDevice
+
+	// routers are the IDs of the expected router devices, keyed by system ID.
+	routers map[string]string
+}
+
+// expect a device, with the given ID, to have data received for it.
+func (inv *inventory) expect(id string, info *jti.DeviceInfo) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if inv.devices == nil {
+		inv.devices = make(map[string]*expectedDevice)
+		inv.routers = make(map[string]string)
+	}
+	if _, ok := inv.devices[id]; ok {
+		return
+	}
+	inv.devices[id] = &expectedDevice{
+		typ:  info.Type,
+		info: info.Info,
+	}
+	if info.Type == "router" {
+		inv.routers[info.Context["system_id"]] = id
+	}
+	metrics.InventoryMissing.WithLabelValues(info.Type).Inc()
+}
+
+// received records that data was received for the device with the given ID. It
+// returns true if the device is expected and this is the first data received for it.
+func (inv *inventory) received(id string) bool {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	dev, ok := inv.devices[id]
+	if !ok || dev.received {
+		return false
+	}
+	dev.received = true
+	metrics.InventoryMissing.WithLabelValues(dev.typ).Dec()
+	return true
+}
+
+// router gets the ID of the expected router device for a system ID.
+func (inv *inventory) router(systemID string) (string, bool) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	id, ok := inv.routers[systemID]
+	return id, ok
+}
+
+// missing gets the info of each expected device which has not had data received for
+// it, in sorted order, along with the total number of expected devices.
+func (inv *inventory) missing() ([]string, int) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	var missing []string
+	for _, dev := range inv.devices {
+		if !dev.received {
+			missing = append(missing, dev.info)
+		}
+	}
+	sort.Strings(missing)
+	return missing, len(inv.devices)
+}
+
+// MissingInventory gets the info of each device of the expected inventory which has
+// not had any data received for it yet, in sorted order.
+func (server *JtiUDPServer) MissingInventory() []string {
+	missing, _ := server.inventory.missing()
+	return missing
+}
+
+// Inventory checks whether data has been received for all of the devices of the expected
+// inventory. An error summarizing the missing devices is returned if it has not.
+func (server *JtiUDPServer) Inventory() error {
+	missing, total := server.inventory.missing()
+	if len(missing) == 0 {
+		return nil
+	}
+
+	listed := missing
+	if len(listed) > maxMissingListed {
+		listed = listed[:maxMissingListed]
+	}
+	summary := strings.Join(listed, ", ")
+	if len(missing) > len(listed) {
+		summary += fmt.Sprintf(", and %d more", len(missing)-len(listed))
+	}
+	return fmt.Errorf("no data received for %d of %d expected device(s): %s", len(missing), total, summary)
+}
+
+// RegisterExpected registers the devices for the routers and interfaces of the expected
+// inventory, so that they exist before any data is received for them. Each device reports
+// a "no data" status until its data is first received.
+func (server *JtiUDPServer) RegisterExpected() error {
+	server.devicesMu.Lock()
+	defer server.devicesMu.Unlock()
+
+	for _, router := range server.expected {
+		info, err := jti.MakeRouterDeviceInfo(router.SystemID)
+		if err != nil {
+			return err
+		}
+		if err := server.registerExpected(info); err != nil {
+			return err
+		}
+
+		for _, iface := range router.Interfaces {
+			info, err := jti.MakeExpectedInterfaceDeviceInfo(router.SystemID, iface)
+			if err != nil {
+				return err
+			}
+			if err := server.registerExpected(info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// registerExpected registers the device for a DeviceInfo of the expected inventory.
+//
+// The caller must hold devicesMu.
+func (server *JtiUDPServer) registerExpected(info *jti.DeviceInfo) error {
+	id, dev, err := server.deviceID(info, nil)
+	if err != nil {
+		return err
+	}
+	// If the device was already built, it is listed in the expected inventory more
+	// than once.
+	if dev == nil {
+		return nil
+	}
+
+	dev.Data[ReadingKey] = dataStatusReadings(dataStatusNoData)
+	if err := server.deviceManager.RegisterDevice(dev); err != nil {
+		log.WithFields(log.Fields{
+			"err":  err,
+			"id":   id,
+			"info": dev.Info,
+		}).Error("[jti] failed to register expected device")
+		return err
+	}
+	server.inventory.expect(id, info)
+	return nil
+}
+
+// receivedFrom records that data was received from a system, updating the status of
+// its router device if the router is expected.
+//
+// The caller must hold devicesMu.
+func (server *JtiUDPServer) receivedFrom(systemID string) {
+	id, ok := server.inventory.router(systemID)
+	if !ok || !server.inventory.received(id) {
+		return
+	}
+	if device := server.deviceManager.GetDevice(id); device != nil {
+		device.Data[ReadingKey] = dataStatusReadings(dataStatusOk)
+	}
+}
+
+// dataStatusReadings gets the readings for a device of the expected inventory which only
+// reports whether its data has been received.
+func dataStatusReadings(status string) []*output.Reading {
+	return []*output.Reading{
+		output.Status.MakeReading(status).WithContext(map[string]string{
+			"metric": "data_status",
+		}),
+	}
+}
@@ -0,0 +1,199 @@
+package protocol
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	sdkconfig "github.com/vapor-ware/synse-sdk/sdk/config"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+// idDeviceManager is a DeviceManager for testing which, unlike the stub device manager,
+// generates a distinct ID for each device from its type and ID components.
+type idDeviceManager struct {
+	devices map[string]*sdk.Device
+}
+
+func newIDDeviceManager() *idDeviceManager {
+	return &idDeviceManager{devices: make(map[string]*sdk.Device)}
+}
+
+func (dm *idDeviceManager) GetDevice(id string) *sdk.Device {
+	return dm.devices[id]
+}
+
+func (dm *idDeviceManager) NewDevice(proto *sdkconfig.DeviceProto, inst *sdkconfig.DeviceInstance) (*sdk.Device, error) {
+	return sdk.NewDeviceFromConfig(proto, inst, map[string]*sdk.DeviceHandler{"jti": {}})
+}
+
+func (dm *idDeviceManager) RegisterDevice(device *sdk.Device) error {
+	dm.devices[dm.GenerateDeviceID(device)] = device
+	return nil
+}
+
+func (dm *idDeviceManager) GenerateDeviceID(device *sdk.Device) string {
+	return fmt.Sprint(device.Type, device.Data["id"])
+}
+
+// interfaceDeviceID gets the ID which the idDeviceManager generates for the device of
+// an interface from the given system, as created from the streams of makeStream.
+func interfaceDeviceID(systemID, name string) string {
+	return fmt.Sprint("interface", map[string]string{"sys": systemID, "if": name, "cid": "0", "scid": "0"})
+}
+
+// dataStatus gets the value of the data status reading of a device, or nil if the device
+// does not have a data status reading.
+func dataStatus(device *sdk.Device) interface{} {
+	readings := device.Data[ReadingKey].([]*output.Reading)
+	if len(readings) != 1 || readings[0].Context["metric"] != "data_status" {
+		return nil
+	}
+	return readings[0].Value
+}
+
+func TestJtiUDPServer_RegisterExpected(t *testing.T) {
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{
+		Address: "localhost",
+		Expected: []config.ExpectedRouter{
+			{
+				SystemID: "r1:10.0.0.1",
+				Interfaces: []config.ExpectedInterface{
+					{Name: "xe-0/0/0"},
+					{Name: "xe-0/0/1"},
+					{Name: "xe-0/0/0"},
+				},
+			},
+			{
+				SystemID: "r2:10.0.0.2",
+			},
+		},
+	}, dm)
+	routers := testutil.ToFloat64(metrics.InventoryMissing.WithLabelValues("router"))
+	interfaces := testutil.ToFloat64(metrics.InventoryMissing.WithLabelValues("interface"))
+
+	assert.NoError(t, svr.RegisterExpected())
+	assert.Len(t, dm.devices, 4)
+	for _, device := range dm.devices {
+		assert.Equal(t, "no data", dataStatus(device))
+	}
+	assert.Equal(t, []string{
+		"r1:10.0.0.1 interface xe-0/0/0",
+		"r1:10.0.0.1 interface xe-0/0/1",
+		"r1:10.0.0.1 router",
+		"r2:10.0.0.2 router",
+	}, svr.MissingInventory())
+	assert.EqualError(t, svr.Inventory(), "no data received for 4 of 4 expected device(s): "+
+		"r1:10.0.0.1 interface xe-0/0/0, r1:10.0.0.1 interface xe-0/0/1, r1:10.0.0.1 router, r2:10.0.0.2 router")
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.InventoryMissing.WithLabelValues("router"))-routers)
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.InventoryMissing.WithLabelValues("interface"))-interfaces)
+
+	// Once data is received for an expected interface, the interface and its router
+	// are no longer missing, and the interface reports its data.
+	svr.process(&packet{data: makeStream(t, "r1:10.0.0.1", "xe-0/0/0"), systemID: "r1:10.0.0.1"})
+	assert.Len(t, dm.devices, 4)
+	assert.Equal(t, []string{
+		"r1:10.0.0.1 interface xe-0/0/1",
+		"r2:10.0.0.2 router",
+	}, svr.MissingInventory())
+	assert.EqualError(t, svr.Inventory(), "no data received for 2 of 4 expected device(s): "+
+		"r1:10.0.0.1 interface xe-0/0/1, r2:10.0.0.2 router")
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.InventoryMissing.WithLabelValues("router"))-routers)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.InventoryMissing.WithLabelValues("interface"))-interfaces)
+
+	for _, device := range dm.devices {
+		switch device.Info {
+		case "r1:10.0.0.1 router":
+			assert.Equal(t, "ok", dataStatus(device))
+		case "r1:10.0.0.1 interface xe-0/0/0":
+			assert.Nil(t, dataStatus(device))
+			assert.Equal(t, "xe-0/0/0", device.Context["interface_name"])
+		default:
+			assert.Equal(t, "no data", dataStatus(device))
+		}
+	}
+}
+
+func TestJtiUDPServer_RegisterExpected_Error(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{
+		Address:  "localhost",
+		Expected: []config.ExpectedRouter{{SystemID: "r1:10.0.0.1"}},
+	}, manager.NewStubDeviceManager(true))
+
+	assert.Error(t, svr.RegisterExpected())
+	assert.Empty(t, svr.MissingInventory())
+}
+
+func TestJtiUDPServer_RegisterExpected_Filtered(t *testing.T) {
+	dm := newIDDeviceManager()
+	cfg := &config.ServerConfig{
+		Address:  "localhost",
+		Health:   config.HealthConfig{Window: time.Minute},
+		Filters:  config.FilterConfig{Interface: config.Filter{Exclude: []string{"em*"}}},
+		Expected: []config.ExpectedRouter{{SystemID: "r1:10.0.0.1"}},
+	}
+	assert.NoError(t, cfg.Filters.Compile())
+	svr := NewJtiUDPServer(cfg, dm)
+	start := time.Now().Add(-2 * time.Minute)
+	svr.freshness.start(start)
+	assert.NoError(t, svr.RegisterExpected())
+	router, _ := svr.inventory.router("r1:10.0.0.1")
+
+	// Data which is entirely filtered does not count as received from the router.
+	svr.process(&packet{data: makeStream(t, "r1:10.0.0.1", "em0"), systemID: "r1:10.0.0.1"})
+	assert.Len(t, dm.devices, 1)
+	assert.Equal(t, "no data", dataStatus(dm.GetDevice(router)))
+	assert.Equal(t, []string{"r1:10.0.0.1 router"}, svr.MissingInventory())
+	assert.EqualError(t, svr.freshness.check(time.Minute, []string{"r1:10.0.0.1"}, time.Now()),
+		"no valid JTI data received within 1m0s from system(s): r1:10.0.0.1")
+
+	svr.process(&packet{data: makeStream(t, "r1:10.0.0.1", "em0", "xe-0/0/0"), systemID: "r1:10.0.0.1"})
+	assert.Equal(t, "ok", dataStatus(dm.GetDevice(router)))
+	assert.Empty(t, svr.MissingInventory())
+	assert.NoError(t, svr.freshness.check(time.Minute, []string{"r1:10.0.0.1"}, time.Now()))
+}
+
+func TestJtiUDPServer_Inventory_NoneExpected(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, manager.NewStubDeviceManager(false))
+
+	assert.NoError(t, svr.RegisterExpected())
+	assert.Empty(t, svr.MissingInventory())
+	assert.NoError(t, svr.Inventory())
+}
+
+func TestJtiUDPServer_Inventory_Truncated(t *testing.T) {
+	svr := JtiUDPServer{}
+	for i := 0; i < 12; i++ {
+		svr.inventory.expect(fmt.Sprint(i), &jti.DeviceInfo{
+			Type: "test",
+			Info: fmt.Sprintf("device %02d", i),
+		})
+	}
+
+	assert.EqualError(t, svr.Inventory(), "no data received for 12 of 12 expected device(s): "+
+		"device 00, device 01, device 02, device 03, device 04, device 05, device 06, device 07, device 08, device 09, and 2 more")
+}
+
+func TestInventory_Received(t *testing.T) {
+	inv := inventory{}
+	inv.expect("r1", &jti.DeviceInfo{Type: "router", Info: "r1 router", Context: map[string]string{"system_id": "r1"}})
+
+	id, ok := inv.router("r1")
+	assert.True(t, ok)
+	assert.Equal(t, "r1", id)
+	_, ok = inv.router("r2")
+	assert.False(t, ok)
+
+	// Only the first data received for an expected device is reported.
+	assert.False(t, inv.received("unexpected"))
+	assert.True(t, inv.received("r1"))
+	assert.False(t, inv.received("r1"))
+}
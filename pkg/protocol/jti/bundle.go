@@ -0,0 +1,293 @@
+package jti
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/outputs"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+// bundleMemberExpiry is the duration after which a bundle member which has not been
+// reported is removed from its bundle, e.g. because it was removed from the bundle
+// without being reported again, or because it stopped reporting altogether.
+const bundleMemberExpiry = 5 * time.Minute
+
+// bundleMember holds the most recent data for an interface which is a member
+// of an Aggregated Ethernet (AE) bundle. Only the data which is aggregated
+// into bundle readings is held.
+type bundleMember struct {
+	name   string
+	active bool
+	speed  uint32
+	seen   time.Time
+
+	ingress       memberStats
+	egress        memberStats
+	ingressErrors uint64
+	egressErrors  uint64
+	ingressDrops  uint64
+	egressDrops   uint64
+}
+
+// memberStats holds the directional traffic counters and rates for a bundle member.
+type memberStats struct {
+	octets       uint64
+	pkts         uint64
+	octetsPerSec uint64
+	pktsPerSec   uint64
+}
+
+// newBundleMember creates a new bundleMember from an InterfaceInfos message.
+func newBundleMember(iface *port.InterfaceInfos) *bundleMember {
+	return &bundleMember{
+		name:   iface.GetIfName(),
+		active: strings.EqualFold(iface.GetIfOperationalStatus(), "up"),
+		speed:  iface.GetIfHighSpeed(),
+		ingress: memberStats{
+			octets:       iface.IngressStats.GetIfOctets(),
+			pkts:         iface.IngressStats.GetIfPkts(),
+			octetsPerSec: iface.IngressStats.GetIf_1SecOctets(),
+			pktsPerSec:   iface.IngressStats.GetIf_1SecPkts(),
+		},
+		egress: memberStats{
+			octets:       iface.EgressStats.GetIfOctets(),
+			pkts:         iface.EgressStats.GetIfPkts(),
+			octetsPerSec: iface.EgressStats.GetIf_1SecOctets(),
+			pktsPerSec:   iface.EgressStats.GetIf_1SecPkts(),
+		},
+		ingressErrors: iface.IngressErrors.GetIfErrors(),
+		egressErrors:  iface.EgressErrors.GetIfErrors(),
+		ingressDrops:  iface.IngressErrors.GetIfDiscards(),
+		egressDrops:   iface.EgressErrors.GetIfDiscards(),
+	}
+}
+
+// bundleReadings is the capacity reserved for the readings of an AE bundle.
+const bundleReadings = 17
+
+// bundleKey identifies an AE bundle on a given system.
+type bundleKey struct {
+	systemID string
+	name     string
+}
+
+// bundleTracker tracks the members of Aggregated Ethernet (AE) bundles across
+// received samples. Member interfaces are typically reported individually (and
+// possibly from different line cards), so the bundle state is built up from the
+// last known data for each of its members.
+//
+// Members which have not been reported within the tracker's expiry are removed from
+// their bundle. Since bundle counters are the sum of the counters of the current
+// members, they are not monotonic across membership changes.
+//
+// A bundleTracker is safe for concurrent use.
+type bundleTracker struct {
+	mu     sync.Mutex
+	expiry time.Duration
+
+	// bundles maps a bundle to its members, keyed by member interface name.
+	bundles map[bundleKey]map[string]*bundleMember
+
+	// membership maps a member interface to the bundle it was last seen in. This is
+	// used to remove members from a bundle when they are moved to a different bundle
+	// or removed from a bundle altogether.
+	membership map[bundleKey]string
+
+	// now gets the current time. It is defined on the tracker so it may be
+	// overridden for testing.
+	now func() time.Time
+}
+
+// newBundleTracker creates a new bundleTracker.
+func newBundleTracker() *bundleTracker {
+	return &bundleTracker{
+		expiry:     bundleMemberExpiry,
+		bundles:    make(map[bundleKey]map[string]*bundleMember),
+		membership: make(map[bundleKey]string),
+		now:        time.Now,
+	}
+}
+
+// Update the tracker with the latest data for an interface. If the interface is
+// a member of a bundle, the name of the bundle is returned. If the interface is
+// not a member of a bundle, an empty string is returned.
+func (tracker *bundleTracker) Update(systemID string, iface *port.InterfaceInfos) string {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	ifaceName := iface.GetIfName()
+	bundleName := iface.GetParentAeName()
+	memberKey := bundleKey{systemID: systemID, name: ifaceName}
+
+	// If the interface was previously a member of a different bundle, remove it
+	// from that bundle.
+	if prev, ok := tracker.membership[memberKey]; ok && prev != bundleName {
+		tracker.remove(systemID, prev, ifaceName)
+	}
+
+	if bundleName == "" {
+		return ""
+	}
+
+	key := bundleKey{systemID: systemID, name: bundleName}
+	members, ok := tracker.bundles[key]
+	if !ok {
+		members = make(map[string]*bundleMember)
+		tracker.bundles[key] = members
+	}
+	member := newBundleMember(iface)
+	member.seen = tracker.now()
+	members[ifaceName] = member
+	tracker.membership[memberKey] = bundleName
+
+	return bundleName
+}
+
+// remove a member from a bundle, removing the bundle if it has no members left.
+// The caller must hold mu.
+func (tracker *bundleTracker) remove(systemID, bundleName, ifaceName string) {
+	key := bundleKey{systemID: systemID, name: bundleName}
+	delete(tracker.bundles[key], ifaceName)
+	if len(tracker.bundles[key]) == 0 {
+		delete(tracker.bundles, key)
+	}
+	delete(tracker.membership, bundleKey{systemID: systemID, name: ifaceName})
+}
+
+// Members gets a snapshot of the members of a bundle, sorted by interface name.
+// Members which have expired are removed from the bundle, and are not included.
+func (tracker *bundleTracker) Members(systemID, bundleName string) []bundleMember {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	members := tracker.bundles[bundleKey{systemID: systemID, name: bundleName}]
+	now := tracker.now()
+
+	var snapshot []bundleMember
+	for name, m := range members {
+		if now.Sub(m.seen) > tracker.expiry {
+			tracker.remove(systemID, bundleName, name)
+			continue
+		}
+		snapshot = append(snapshot, *m)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].name < snapshot[j].name
+	})
+	return snapshot
+}
+
+// MakeBundleDeviceInfo creates a DeviceInfo for an Aggregated Ethernet (AE) bundle. The
+// DeviceInfo is used to generate SDK devices.
+//
+// Bundle members may be reported by different line cards, so the component and
+// sub-component IDs are not used to identify the bundle.
+func (ctx *PortContext) MakeBundleDeviceInfo(bundleName string) (*DeviceInfo, error) {
+	if ctx.SystemID == "" {
+		return nil, errors.New("unable to load bundle device info from port context: context has no system ID")
+	}
+
+	if bundleName == "" {
+		return nil, errors.New("unable to load bundle device info from port context: bundle has no name")
+	}
+
+	return &DeviceInfo{
+		Type: "ae-bundle",
+		Info: fmt.Sprintf("%s ae-bundle %s", ctx.SystemID, bundleName),
+		Tags: []string{
+			"vapor/networking:ae-bundle",
+		},
+		Context: map[string]string{
+			"interface_name": bundleName,
+			"system_id":      ctx.SystemID,
+			"metric_type":    "network",
+		},
+		IDComponents: map[string]string{
+			"sys": ctx.SystemID,
+			"ae":  bundleName,
+		},
+	}, nil
+}
+
+// MakeBundleReadings creates device readings for an Aggregated Ethernet (AE) bundle from
+// the last known data of each of its members. Counters and rates are summed across all
+// members and the bundle capacity is the sum of the member speeds. As the counters are
+// summed across the current members, they decrease when a member leaves the bundle.
+func (ctx *PortContext) MakeBundleReadings(members []bundleMember) ([]*output.Reading, error) {
+	var (
+		active        int
+		capacity      uint32
+		ingress       memberStats
+		egress        memberStats
+		ingressErrors uint64
+		egressErrors  uint64
+		ingressDrops  uint64
+		egressDrops   uint64
+	)
+
+	for _, m := range members {
+		if m.active {
+			active++
+		}
+		capacity += m.speed
+
+		ingress.octets += m.ingress.octets
+		ingress.pkts += m.ingress.pkts
+		ingress.octetsPerSec += m.ingress.octetsPerSec
+		ingress.pktsPerSec += m.ingress.pktsPerSec
+		egress.octets += m.egress.octets
+		egress.pkts += m.egress.pkts
+		egress.octetsPerSec += m.egress.octetsPerSec
+		egress.pktsPerSec += m.egress.pktsPerSec
+
+		ingressErrors += m.ingressErrors
+		egressErrors += m.egressErrors
+		ingressDrops += m.ingressDrops
+		egressDrops += m.egressDrops
+	}
+
+	set := newReadingSet(bundleReadings, metricFilter(ctx.filters))
+
+	// -*- Number Outputs -*-
+	set.add(&output.Number, len(members), metricContext("member_count"))
+	set.add(&output.Number, active, metricContext("active_member_count"))
+
+	// -*- Megabits per second Outputs -*-
+	set.add(&outputs.MegabitPerSecond, capacity, metricContext("if_high_speed"))
+
+	// -*- Bytes Counter Outputs -*-
+	set.add(&outputs.BytesCounter, ingress.octets, directionContext("ingress", "if_octets"))
+	set.add(&outputs.BytesCounter, egress.octets, directionContext("egress", "if_octets"))
+
+	// -*- Bytes per Second Outputs -*-
+	set.add(&outputs.BytesPerSecond, ingress.octetsPerSec, directionContext("ingress", "if_1sec_octets"))
+	set.add(&outputs.BytesPerSecond, egress.octetsPerSec, directionContext("egress", "if_1sec_octets"))
+
+	// -*- Packets Counter Outputs -*-
+	set.add(&outputs.PacketsCounter, ingress.pkts, directionContext("ingress", "if_pkts"))
+	set.add(&outputs.PacketsCounter, egress.pkts, directionContext("egress", "if_pkts"))
+	set.add(&outputs.PacketsCounter, ingressErrors, directionContext("ingress", "if_errors"))
+	set.add(&outputs.PacketsCounter, egressErrors, directionContext("egress", "if_errors"))
+	set.add(&outputs.PacketsCounter, ingressDrops, directionContext("ingress", "if_discards"))
+	set.add(&outputs.PacketsCounter, egressDrops, directionContext("egress", "if_discards"))
+
+	// -*- Packets per Second Outputs -*-
+	set.add(&outputs.PacketsPerSecond, ingress.pktsPerSec, directionContext("ingress", "if_1sec_pkts"))
+	set.add(&outputs.PacketsPerSecond, egress.pktsPerSec, directionContext("egress", "if_1sec_pkts"))
+
+	// -*- Percent Outputs -*-
+	if pct, ok := utilizationPercent(ingress.octetsPerSec, capacity); ok {
+		set.add(&outputs.Percent, pct, directionContext("ingress", "if_utilization"))
+	}
+	if pct, ok := utilizationPercent(egress.octetsPerSec, capacity); ok {
+		set.add(&outputs.Percent, pct, directionContext("egress", "if_utilization"))
+	}
+
+	return set.Readings(), nil
+}
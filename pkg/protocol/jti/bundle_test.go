@@ -0,0 +1,220 @@
+package jti
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+func makeMember(name, bundle, status string, speed uint32, octets uint64) *port.InterfaceInfos {
+	return &port.InterfaceInfos{
+		IfName:              &name,
+		ParentAeName:        &bundle,
+		IfOperationalStatus: &status,
+		IfHighSpeed:         &speed,
+		IngressStats: &port.InterfaceStats{
+			IfOctets:      &octets,
+			If_1SecOctets: &octets,
+		},
+		EgressStats: &port.InterfaceStats{
+			IfOctets:      &octets,
+			If_1SecOctets: &octets,
+		},
+	}
+}
+
+func readingsByMetric(readings []*output.Reading, direction string) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, r := range readings {
+		if r.Context["direction"] == direction {
+			values[r.Context["metric"]] = r.Value
+		}
+	}
+	return values
+}
+
+func TestBundleTracker_Update(t *testing.T) {
+	tracker := newBundleTracker()
+
+	bundle := tracker.Update("sys", makeMember("et-0/0/0", "ae0", "UP", 100000, 1))
+	assert.Equal(t, "ae0", bundle)
+	bundle = tracker.Update("sys", makeMember("et-0/0/1", "ae0", "UP", 100000, 1))
+	assert.Equal(t, "ae0", bundle)
+
+	members := tracker.Members("sys", "ae0")
+	assert.Len(t, members, 2)
+	assert.Equal(t, "et-0/0/0", members[0].name)
+	assert.Equal(t, "et-0/0/1", members[1].name)
+}
+
+func TestBundleTracker_Update_NotMember(t *testing.T) {
+	tracker := newBundleTracker()
+
+	bundle := tracker.Update("sys", makeMember("et-0/0/0", "", "UP", 100000, 1))
+	assert.Equal(t, "", bundle)
+	assert.Empty(t, tracker.bundles)
+}
+
+func TestBundleTracker_Update_MemberMoved(t *testing.T) {
+	tracker := newBundleTracker()
+
+	tracker.Update("sys", makeMember("et-0/0/0", "ae0", "UP", 100000, 1))
+	tracker.Update("sys", makeMember("et-0/0/1", "ae0", "UP", 100000, 1))
+	tracker.Update("sys", makeMember("et-0/0/1", "ae1", "UP", 100000, 1))
+
+	assert.Len(t, tracker.Members("sys", "ae0"), 1)
+	assert.Len(t, tracker.Members("sys", "ae1"), 1)
+
+	// Removing the last member removes the bundle.
+	tracker.Update("sys", makeMember("et-0/0/1", "", "UP", 100000, 1))
+	assert.Len(t, tracker.Members("sys", "ae1"), 0)
+	assert.NotContains(t, tracker.bundles, bundleKey{systemID: "sys", name: "ae1"})
+}
+
+func TestBundleTracker_Update_SeparateSystems(t *testing.T) {
+	tracker := newBundleTracker()
+
+	tracker.Update("sys-1", makeMember("et-0/0/0", "ae0", "UP", 100000, 1))
+	tracker.Update("sys-2", makeMember("et-0/0/0", "ae0", "UP", 100000, 1))
+
+	assert.Len(t, tracker.Members("sys-1", "ae0"), 1)
+	assert.Len(t, tracker.Members("sys-2", "ae0"), 1)
+}
+
+func TestPortContext_MakeBundleDeviceInfo(t *testing.T) {
+	ctx := PortContext{
+		SensorName:     "sensor",
+		SystemID:       "test",
+		ComponentID:    2,
+		SubComponentID: 0,
+	}
+
+	info, err := ctx.MakeBundleDeviceInfo("ae0")
+	assert.NoError(t, err)
+	assert.Equal(t, "ae-bundle", info.Type)
+	assert.Equal(t, "test ae-bundle ae0", info.Info)
+	assert.Equal(t, []string{"vapor/networking:ae-bundle"}, info.Tags)
+	assert.Equal(t, map[string]string{
+		"interface_name": "ae0",
+		"system_id":      "test",
+		"metric_type":    "network",
+	}, info.Context)
+	assert.Equal(t, map[string]string{
+		"sys": "test",
+		"ae":  "ae0",
+	}, info.IDComponents)
+}
+
+func TestPortContext_MakeBundleDeviceInfo_ErrNoSystemID(t *testing.T) {
+	ctx := PortContext{}
+
+	info, err := ctx.MakeBundleDeviceInfo("ae0")
+	assert.Error(t, err)
+	assert.Nil(t, info)
+}
+
+func TestPortContext_MakeBundleDeviceInfo_ErrNoName(t *testing.T) {
+	ctx := PortContext{SystemID: "test"}
+
+	info, err := ctx.MakeBundleDeviceInfo("")
+	assert.Error(t, err)
+	assert.Nil(t, info)
+}
+
+func TestPortContext_MakeBundleReadings(t *testing.T) {
+	ctx := PortContext{SystemID: "test"}
+	tracker := newBundleTracker()
+	tracker.Update("test", makeMember("et-0/0/0", "ae0", "UP", 1000, 62500000))
+	tracker.Update("test", makeMember("et-0/0/1", "ae0", "DOWN", 1000, 0))
+
+	readings, err := ctx.MakeBundleReadings(tracker.Members("test", "ae0"))
+	assert.NoError(t, err)
+	assert.Len(t, readings, 17)
+
+	values := readingsByMetric(readings, "")
+	assert.Equal(t, 2, values["member_count"])
+	assert.Equal(t, 1, values["active_member_count"])
+	assert.Equal(t, uint32(2000), values["if_high_speed"])
+
+	ingress := readingsByMetric(readings, "ingress")
+	assert.Equal(t, uint64(62500000), ingress["if_octets"])
+	assert.Equal(t, uint64(62500000), ingress["if_1sec_octets"])
+	assert.Equal(t, float64(25), ingress["if_utilization"])
+}
+
+func TestPortContext_MakeBundleReadings_NoCapacity(t *testing.T) {
+	ctx := PortContext{SystemID: "test"}
+	tracker := newBundleTracker()
+	tracker.Update("test", makeMember("et-0/0/0", "ae0", "DOWN", 0, 0))
+
+	readings, err := ctx.MakeBundleReadings(tracker.Members("test", "ae0"))
+	assert.NoError(t, err)
+	assert.Len(t, readings, 15)
+}
+
+func TestPortContext_Decode_WithBundles(t *testing.T) {
+	ctx := PortContext{
+		SensorName:     "sensor",
+		SystemID:       "test",
+		ComponentID:    2,
+		SubComponentID: 0,
+		bundles:        newBundleTracker(),
+	}
+
+	data, err := ctx.Decode(&port.Port{
+		InterfaceStats: []*port.InterfaceInfos{
+			makeMember("et-0/0/0", "ae0", "UP", 1000, 1),
+			makeMember("et-0/0/1", "ae0", "UP", 1000, 1),
+			makeMember("et-0/0/2", "", "UP", 1000, 1),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, data, 4)
+	assert.Equal(t, "ae-bundle", data[3].DeviceInfo.Type)
+
+	// A subsequent message for a single member still updates the bundle with the
+	// data from all known members.
+	data, err = ctx.Decode(&port.Port{
+		InterfaceStats: []*port.InterfaceInfos{
+			makeMember("et-0/0/1", "ae0", "DOWN", 1000, 1),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, data, 2)
+	assert.Equal(t, "ae-bundle", data[1].DeviceInfo.Type)
+
+	values := readingsByMetric(data[1].Readings, "")
+	assert.Equal(t, 2, values["member_count"])
+	assert.Equal(t, 1, values["active_member_count"])
+}
+
+func TestBundleTracker_Members_Expired(t *testing.T) {
+	clock := newTestClock()
+	tracker := newBundleTracker()
+	tracker.now = clock.now
+
+	tracker.Update("sys", makeMember("et-0/0/0", "ae0", "UP", 100000, 1))
+	tracker.Update("sys", makeMember("et-0/0/1", "ae0", "UP", 100000, 1))
+
+	// Members which are still reported remain in the bundle, while members which
+	// stopped reporting are removed once they expire.
+	clock.advance(bundleMemberExpiry)
+	tracker.Update("sys", makeMember("et-0/0/0", "ae0", "UP", 100000, 1))
+	assert.Len(t, tracker.Members("sys", "ae0"), 2)
+
+	clock.advance(time.Second)
+	members := tracker.Members("sys", "ae0")
+	if assert.Len(t, members, 1) {
+		assert.Equal(t, "et-0/0/0", members[0].name)
+	}
+	assert.NotContains(t, tracker.membership, bundleKey{systemID: "sys", name: "et-0/0/1"})
+
+	// Once all of the members expire, the bundle is removed.
+	clock.advance(bundleMemberExpiry + time.Second)
+	assert.Len(t, tracker.Members("sys", "ae0"), 0)
+	assert.Empty(t, tracker.bundles)
+	assert.Empty(t, tracker.membership)
+}
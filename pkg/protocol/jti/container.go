@@ -18,4 +18,9 @@ type DeviceInfo struct {
 	Tags         []string
 	Context      map[string]string
 	IDComponents map[string]string
+
+	// Links are the DeviceInfos of devices which this device is related to, keyed
+	// by the device context key that the ID of the related device is stored under.
+	// For example, an optic device links to the interface device it belongs to.
+	Links map[string]*DeviceInfo
 }
@@ -3,13 +3,21 @@ package jti
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/golang/protobuf/proto"
 	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/opencfg"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/optics"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // JuniperJTIDecoder is used to decode bytes from the UDP data stream
@@ -20,13 +28,104 @@ import (
 // https://www.juniper.net/documentation/en_US/junos/topics/reference/general/junos-telemetry-interface-grpc-sensors.html
 type JuniperJTIDecoder struct {
 	deviceManager manager.DeviceManager
+
+	// bundles tracks Aggregated Ethernet bundle membership across all decoded
+	// port messages so that bundle devices can be built from their members.
+	bundles *bundleTracker
+
+	// hysteresis defines the margins applied when evaluating optics thresholds.
+	hysteresis config.HysteresisConfig
+
+	// opticsTypes maps optics type codes to transceiver types, taking precedence
+	// over the built-in mapping.
+	opticsTypes map[uint32]config.OpticsType
+
+	// thresholds tracks the evaluated severity of optics values across all decoded
+	// optics messages so that hysteresis can be applied.
+	thresholds *thresholdTracker
+
+	// flaps tracks interface transitions across all decoded port messages in
+	// order to detect link flaps.
+	flaps *flapTracker
+
+	// redundancy de-duplicates the data of redundant streams. It is nil if
+	// de-duplication is not enabled.
+	redundancy *redundancyTracker
+
+	// filters define which of the decoded data is dropped before it is returned.
+	filters config.FilterConfig
+
+	// streamTags enables tagging devices with the parsed stream info.
+	streamTags bool
+
+	// interfaces records the interface devices found in port messages so that
+	// optics devices can be linked to the interface they belong to.
+	interfaces *interfaceIndex
+
+	// extensions are the decoders for each supported sensor extension, keyed
+	// by the extension's descriptor.
+	extensions map[protoreflect.ExtensionType]SensorDecoder
+}
+
+// openConfigField is the field number of the IETFSensors branch which carries
+// OpenConfig key/value data.
+const openConfigField protowire.Number = 1
+
+// builtinSensorExtensions are the sensor extensions which the plugin decodes itself.
+var builtinSensorExtensions = []protoreflect.ExtensionType{
+	port.E_JnprInterfaceExt,
+	optics.E_JnprOpticsExt,
+}
+
+// isBuiltinSensor checks whether a sensor extension is decoded by the plugin itself.
+func isBuiltinSensor(ext protoreflect.ExtensionType) bool {
+	for _, builtin := range builtinSensorExtensions {
+		if builtin == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// streams holds TelemetryStream messages so that they can be reused across decodes
+// rather than allocated for every received packet.
+var streams = sync.Pool{
+	New: func() interface{} {
+		return &telemetry_top.TelemetryStream{}
+	},
 }
 
 // NewJTIDecoder creates a new JuniperJTIDecoder.
-func NewJTIDecoder(deviceManager manager.DeviceManager) *JuniperJTIDecoder {
-	return &JuniperJTIDecoder{
+//
+// Any sensor decoders given decode sensor extensions which are not supported by the
+// plugin itself. They should be checked with ValidateSensorDecoders; decoders for the
+// plugin's own extensions are ignored.
+func NewJTIDecoder(c *config.ServerConfig, deviceManager manager.DeviceManager, sensors ...SensorDecoder) *JuniperJTIDecoder {
+	decoder := &JuniperJTIDecoder{
 		deviceManager: deviceManager,
+		bundles:       newBundleTracker(),
+		hysteresis:    c.Optics.Hysteresis,
+		opticsTypes:   c.Optics.Types,
+		thresholds:    newThresholdTracker(),
+		flaps:         newFlapTracker(c.Flaps.Windows),
+		filters:       c.Filters,
+		streamTags:    c.StreamTags,
+		interfaces:    newInterfaceIndex(),
 	}
+	if c.Redundancy.Enabled {
+		failover := c.Redundancy.Failover
+		if failover <= 0 {
+			failover = config.DefaultFailover
+		}
+		decoder.redundancy = newRedundancyTracker(failover)
+	}
+	decoder.extensions = make(map[protoreflect.ExtensionType]SensorDecoder, len(sensors)+2)
+	for _, d := range sensors {
+		decoder.extensions[d.Extension()] = d
+	}
+	decoder.extensions[port.E_JnprInterfaceExt] = NewSensorDecoder(port.E_JnprInterfaceExt, decoder.decodePort)
+	decoder.extensions[optics.E_JnprOpticsExt] = NewSensorDecoder(optics.E_JnprOpticsExt, decoder.decodeOptics)
+	return decoder
 }
 
 // Decode the given bytes into a format consumable by the Synse platform.
@@ -35,11 +134,26 @@ func (decoder *JuniperJTIDecoder) Decode(buffer []byte) ([]*IntermediaryDataCont
 		return nil, errors.New("JTI decoder does not have a device manager defined")
 	}
 
-	ts := &telemetry_top.TelemetryStream{}
+	ts := streams.Get().(*telemetry_top.TelemetryStream)
+	defer func() {
+		ts.Reset()
+		streams.Put(ts)
+	}()
 	if err := proto.Unmarshal(buffer, ts); err != nil {
 		return nil, err
 	}
 
+	if !decoder.filters.SystemID.Allows(ts.GetSystemId()) {
+		log.WithField("system_id", ts.GetSystemId()).Debug("[jti] message filtered by system ID")
+		metrics.Filtered.WithLabelValues("system_id").Inc()
+		return nil, nil
+	}
+	if !decoder.filters.Sensor.Allows(ts.GetSensorName()) {
+		log.WithField("sensor", ts.GetSensorName()).Debug("[jti] message filtered by sensor name")
+		metrics.Filtered.WithLabelValues("sensor").Inc()
+		return nil, nil
+	}
+
 	var decoded []*IntermediaryDataContainer
 
 	if proto.HasExtension(ts.Enterprise, telemetry_top.E_JuniperNetworks) {
@@ -51,58 +165,11 @@ func (decoder *JuniperJTIDecoder) Decode(buffer []byte) ([]*IntermediaryDataCont
 
 		switch jns := jnsIface.(type) {
 		case *telemetry_top.JuniperNetworksSensors:
-
-			if proto.HasExtension(jns, optics.E_JnprOpticsExt) {
-				/*
-					OPTICS
-				*/
-				opticsIface, err := proto.GetExtension(jns, optics.E_JnprOpticsExt)
-				if err != nil {
-					log.WithError(err).Error("[jti] failed to get extension")
-					return nil, err
-				}
-
-				switch opt := opticsIface.(type) {
-				case *optics.Optics:
-					res, err := NewOpticsContextFromStream(ts).Decode(opt)
-					if err != nil {
-						return nil, err
-					}
-					decoded = append(decoded, res...)
-				default:
-					log.Error("[jti] found no matching optics iface")
-					return nil, fmt.Errorf("found no matching optics interface")
-				}
-
-			} else if proto.HasExtension(jns, port.E_JnprInterfaceExt) {
-				/*
-					PORT INTERFACE
-				*/
-				portIface, err := proto.GetExtension(jns, port.E_JnprInterfaceExt)
-				if err != nil {
-					log.WithError(err).Error("[jti] failed to get extension")
-					return nil, err
-				}
-
-				switch p := portIface.(type) {
-				case *port.Port:
-					res, err := NewPortContextFromStream(ts).Decode(p)
-					if err != nil {
-						return nil, err
-					}
-					decoded = append(decoded, res...)
-				default:
-					log.Error("[jti] found no matching port iface")
-					return nil, fmt.Errorf("found no matching port interface")
-				}
-			} else {
-				/*
-					UNKNOWN
-				*/
-				// TODO (etd): Figure out how to get an identifier for the extension to log.
-				// 	 It is not immediately intuitive how to do this.
-				log.Info("[jti] received message with extension not currently supported by the plugin")
+			res, err := decoder.decodeExtensions(ts, jns)
+			if err != nil {
+				return nil, err
 			}
+			decoded = append(decoded, res...)
 
 		default:
 			log.WithFields(log.Fields{
@@ -110,9 +177,244 @@ func (decoder *JuniperJTIDecoder) Decode(buffer []byte) ([]*IntermediaryDataCont
 			}).Warning("[jti] unsupported JTI protobuf extension")
 		}
 
-	} else {
-		log.Warning("[jti] message does not provide juniper network extension")
+	} else if ts.Ietf == nil {
+		log.Warning("[jti] message does not provide juniper network extension or ietf sensors")
+	}
+
+	if ts.Ietf != nil {
+		res, err := decoder.decodeIETF(ts)
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, res...)
+	}
+
+	decoded = decoder.filter(decoded)
+
+	streamInfo := NewStreamInfo(ts)
+
+	// Apply the information parsed from the stream's system ID and sensor
+	// name to all of the devices found in the stream.
+	for _, d := range decoded {
+		streamInfo.Apply(d.DeviceInfo, decoder.streamTags)
 	}
 
 	return decoded, nil
 }
+
+// decodeExtensions decodes all of the sensor extensions which are populated in a
+// JuniperNetworksSensors message, concatenating their results. Extensions are decoded
+// in order of their field number so that the results are deterministic.
+//
+// Extensions which do not have a decoder are logged and skipped.
+func (decoder *JuniperJTIDecoder) decodeExtensions(ts *telemetry_top.TelemetryStream, jns *telemetry_top.JuniperNetworksSensors) ([]*IntermediaryDataContainer, error) {
+	type populated struct {
+		desc protoreflect.ExtensionTypeDescriptor
+		msg  proto.Message
+	}
+
+	var extensions []populated
+	proto.MessageReflect(jns).Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if xd, ok := fd.(protoreflect.ExtensionTypeDescriptor); ok && fd.Message() != nil {
+			extensions = append(extensions, populated{
+				desc: xd,
+				msg:  proto.MessageV1(v.Message().Interface()),
+			})
+		}
+		return true
+	})
+	sort.Slice(extensions, func(i, j int) bool {
+		return extensions[i].desc.Number() < extensions[j].desc.Number()
+	})
+
+	// The data of the built-in decoders is de-duplicated as it is decoded. The data of
+	// all other decoders is de-duplicated once decoded.
+	var decoded, other []*IntermediaryDataContainer
+	for _, ext := range extensions {
+		sensor, ok := decoder.extensions[ext.desc.Type()]
+		if !ok {
+			log.WithFields(log.Fields{
+				"field": ext.desc.Number(),
+				"name":  ext.desc.FullName(),
+			}).Info("[jti] received message with extension not currently supported by the plugin")
+			continue
+		}
+
+		res, err := sensor.Decode(ts, ext.msg)
+		if err != nil {
+			return nil, err
+		}
+		if isBuiltinSensor(ext.desc.Type()) {
+			decoded = append(decoded, res...)
+		} else {
+			other = append(other, res...)
+		}
+	}
+	decoded = append(decoded, decoder.redundancy.Filter(ts, other)...)
+
+	// Extensions which are not known to the plugin at all are not parsed, so they
+	// remain in the message's unknown fields. Only their field number is available.
+	rangeUnknownFields(jns, func(num protowire.Number, _ protowire.Type, _ []byte) {
+		log.WithFields(log.Fields{
+			"field": num,
+			"name":  "unknown",
+		}).Info("[jti] received message with extension not currently supported by the plugin")
+	})
+
+	return decoded, nil
+}
+
+// decodeIETF decodes the OpenConfig key/value data carried in the IETF sensors of a
+// TelemetryStream.
+//
+// The IETF sensor branches do not have extensions defined for them, so the data is
+// carried in the unknown fields of the message. Only the openConfigField branch is
+// decoded, and only if it holds OpenConfig key/value data.
+func (decoder *JuniperJTIDecoder) decodeIETF(ts *telemetry_top.TelemetryStream) ([]*IntermediaryDataContainer, error) {
+	var decoded []*IntermediaryDataContainer
+	var err error
+
+	rangeUnknownFields(ts.Ietf, func(num protowire.Number, typ protowire.Type, value []byte) {
+		if err != nil {
+			return
+		}
+
+		data := &opencfg.OpenConfigData{}
+		if num != openConfigField || typ != protowire.BytesType || proto.Unmarshal(value, data) != nil || !isOpenConfigData(data) {
+			log.WithFields(log.Fields{
+				"field": num,
+			}).Info("[jti] received ietf sensor data not currently supported by the plugin")
+			return
+		}
+
+		var res []*IntermediaryDataContainer
+		res, err = NewOpenConfigContextFromStream(ts).Decode(data)
+		decoded = append(decoded, res...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decoder.redundancy.Filter(ts, decoded), nil
+}
+
+// isOpenConfigData checks whether a message parsed as OpenConfigData holds OpenConfig
+// key/value data. Other payloads may parse as OpenConfigData, but their keys are not
+// paths: the path of each leaf must be absolute.
+func isOpenConfigData(data *opencfg.OpenConfigData) bool {
+	if len(data.GetKv()) == 0 {
+		return false
+	}
+	for _, kv := range data.GetKv() {
+		if !strings.HasPrefix(joinOpenConfigPath(data.GetPath(), kv.GetKey()), "/") {
+			return false
+		}
+	}
+	return true
+}
+
+// rangeUnknownFields calls fn for each of the unknown fields of a message. For fields
+// with the bytes wire type, the value is the content of the field.
+func rangeUnknownFields(m proto.Message, fn func(num protowire.Number, typ protowire.Type, value []byte)) {
+	for b := proto.MessageReflect(m).GetUnknown(); len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return
+		}
+		b = b[n:]
+
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return
+		}
+		value := b[:n]
+		if typ == protowire.BytesType {
+			value, _ = protowire.ConsumeBytes(value)
+		}
+		b = b[n:]
+
+		fn(num, typ, value)
+	}
+}
+
+// decodeOptics decodes an optics sensor extension.
+func (decoder *JuniperJTIDecoder) decodeOptics(ts *telemetry_top.TelemetryStream, msg proto.Message) ([]*IntermediaryDataContainer, error) {
+	opt, ok := msg.(*optics.Optics)
+	if !ok {
+		log.Error("[jti] found no matching optics iface")
+		return nil, fmt.Errorf("found no matching optics interface")
+	}
+
+	ctx := NewOpticsContextFromStream(ts)
+	ctx.Hysteresis = decoder.hysteresis
+	ctx.Types = decoder.opticsTypes
+	ctx.thresholds = decoder.thresholds
+	ctx.interfaces = decoder.interfaces
+	ctx.filters = &decoder.filters
+	ctx.redundancy = decoder.redundancy.Stream(ts)
+	return ctx.Decode(opt)
+}
+
+// decodePort decodes a port interface sensor extension.
+func (decoder *JuniperJTIDecoder) decodePort(ts *telemetry_top.TelemetryStream, msg proto.Message) ([]*IntermediaryDataContainer, error) {
+	p, ok := msg.(*port.Port)
+	if !ok {
+		log.Error("[jti] found no matching port iface")
+		return nil, fmt.Errorf("found no matching port interface")
+	}
+
+	ctx := NewPortContextFromStream(ts)
+	ctx.bundles = decoder.bundles
+	ctx.flaps = decoder.flaps
+	ctx.interfaces = decoder.interfaces
+	ctx.filters = &decoder.filters
+	ctx.redundancy = decoder.redundancy.Stream(ts)
+	return ctx.Decode(p)
+}
+
+// filter drops the devices and readings from the decoded data which are not allowed
+// by the configured interface and metric filters.
+//
+// The port and optics decoders apply the filters themselves, before any readings are
+// built, so that filtered data does not update the trackers. This filters the data of
+// the other decoders, e.g. OpenConfig and custom sensor decoders, once decoded.
+func (decoder *JuniperJTIDecoder) filter(decoded []*IntermediaryDataContainer) []*IntermediaryDataContainer {
+	var filtered []*IntermediaryDataContainer
+	for _, d := range decoded {
+		if !decoder.filters.Interface.Allows(d.DeviceInfo.Context["interface_name"]) {
+			metrics.Filtered.WithLabelValues("interface").Inc()
+			continue
+		}
+
+		readings := d.Readings[:0]
+		for _, r := range d.Readings {
+			if !decoder.filters.Metric.Allows(r.Context["metric"]) {
+				metrics.Filtered.WithLabelValues("metric").Inc()
+				continue
+			}
+			readings = append(readings, r)
+		}
+		d.Readings = readings
+
+		filtered = append(filtered, d)
+	}
+	return filtered
+}
+
+// allowsInterface checks whether the filters allow the data of an interface, counting
+// the interface if it is filtered. If the filters are nil, all interfaces are allowed.
+func allowsInterface(filters *config.FilterConfig, name string) bool {
+	if filters == nil || filters.Interface.Allows(name) {
+		return true
+	}
+	metrics.Filtered.WithLabelValues("interface").Inc()
+	return false
+}
+
+// metricFilter gets the filter for reading metrics from the filters, or nil if the
+// filters are nil.
+func metricFilter(filters *config.FilterConfig) *config.Filter {
+	if filters == nil {
+		return nil
+	}
+	return &filters.Metric
+}
@@ -1,14 +1,25 @@
 package jti
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/opencfg"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/optics"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/runtime/protoimpl"
 )
 
 func TestNewJTIDecoder(t *testing.T) {
-	decoder := NewJTIDecoder(manager.NewStubDeviceManager(false))
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
 	assert.NotNil(t, decoder)
 }
 
@@ -21,9 +32,368 @@ func TestJuniperJTIDecoder_Decode_NilManager(t *testing.T) {
 }
 
 func TestJuniperJTIDecoder_Decode_FailedDecode(t *testing.T) {
-	decoder := NewJTIDecoder(manager.NewStubDeviceManager(false))
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
 
 	data, err := decoder.Decode([]byte{})
 	assert.Error(t, err)
 	assert.Nil(t, data)
 }
+
+// makeStream creates the encoded bytes for a TelemetryStream message with the given
+// Juniper sensor extensions set.
+func makeStream(t assert.TestingT, systemID, sensor string, exts map[*protoimpl.ExtensionInfo]interface{}) []byte {
+	jns := &telemetry_top.JuniperNetworksSensors{}
+	for ext, val := range exts {
+		assert.NoError(t, proto.SetExtension(jns, ext, val))
+	}
+
+	enterprise := &telemetry_top.EnterpriseSensors{}
+	assert.NoError(t, proto.SetExtension(enterprise, telemetry_top.E_JuniperNetworks, jns))
+
+	ts := &telemetry_top.TelemetryStream{
+		SystemId:   &systemID,
+		SensorName: &sensor,
+		Enterprise: enterprise,
+	}
+	b, err := proto.Marshal(ts)
+	assert.NoError(t, err)
+	return b
+}
+
+func makePort(names ...string) *port.Port {
+	p := &port.Port{}
+	for i := range names {
+		p.InterfaceStats = append(p.InterfaceStats, &port.InterfaceInfos{
+			IfName: &names[i],
+		})
+	}
+	return p
+}
+
+func TestJuniperJTIDecoder_Decode_Port(t *testing.T) {
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
+
+	data, err := decoder.Decode(makeStream(t, "router1", "sensor", map[*protoimpl.ExtensionInfo]interface{}{
+		port.E_JnprInterfaceExt: makePort("et-0/0/0", "et-0/0/1"),
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, data, 2)
+}
+
+func TestJuniperJTIDecoder_Decode_MultipleExtensions(t *testing.T) {
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
+	name := "et-0/0/0"
+
+	data, err := decoder.Decode(makeStream(t, "router1", "sensor", map[*protoimpl.ExtensionInfo]interface{}{
+		optics.E_JnprOpticsExt: &optics.Optics{
+			OpticsDiag: []*optics.OpticsInfos{{IfName: &name}},
+		},
+		port.E_JnprInterfaceExt: makePort(name),
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, data, 2)
+
+	// Extensions are decoded in order of their field number, so the interface is
+	// known by the time the optic is decoded.
+	assert.Equal(t, "interface", data[0].DeviceInfo.Type)
+	assert.Equal(t, "optic", data[1].DeviceInfo.Type)
+	assert.Equal(t, data[0].DeviceInfo.IDComponents, data[1].DeviceInfo.Links["interface_device_id"].IDComponents)
+}
+
+func TestJuniperJTIDecoder_Decode_UnsupportedExtension(t *testing.T) {
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
+	delete(decoder.extensions, optics.E_JnprOpticsExt)
+	name := "et-0/0/0"
+
+	data, err := decoder.Decode(makeStream(t, "router1", "sensor", map[*protoimpl.ExtensionInfo]interface{}{
+		optics.E_JnprOpticsExt: &optics.Optics{
+			OpticsDiag: []*optics.OpticsInfos{{IfName: &name}},
+		},
+		port.E_JnprInterfaceExt: makePort(name),
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+	assert.Equal(t, "interface", data[0].DeviceInfo.Type)
+}
+
+func TestJuniperJTIDecoder_Decode_UnknownExtension(t *testing.T) {
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
+
+	// An extension which is not known to the plugin is left in the unknown fields.
+	jns := &telemetry_top.JuniperNetworksSensors{}
+	unknown := protowire.AppendTag(nil, 42, protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, []byte{0x08, 0x01})
+	proto.MessageReflect(jns).SetUnknown(unknown)
+	assert.NoError(t, proto.SetExtension(jns, port.E_JnprInterfaceExt, makePort("et-0/0/0")))
+
+	enterprise := &telemetry_top.EnterpriseSensors{}
+	assert.NoError(t, proto.SetExtension(enterprise, telemetry_top.E_JuniperNetworks, jns))
+	systemID := "router1"
+	b, err := proto.Marshal(&telemetry_top.TelemetryStream{
+		SystemId:   &systemID,
+		Enterprise: enterprise,
+	})
+	assert.NoError(t, err)
+
+	data, err := decoder.Decode(b)
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+}
+
+func TestJuniperJTIDecoder_Decode_IETF(t *testing.T) {
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
+
+	oc, err := proto.Marshal(&opencfg.OpenConfigData{
+		Path: "/interfaces/interface[name='et-0/0/0']/",
+		Kv: []*opencfg.KeyValue{
+			{Key: "state/counters/in-octets", Value: &opencfg.KeyValue_UintValue{UintValue: 100}},
+		},
+	})
+	assert.NoError(t, err)
+
+	// The IETF sensor branches are not defined, so they are carried as unknown fields.
+	ietf := &telemetry_top.IETFSensors{}
+	unknown := protowire.AppendTag(nil, 1, protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, oc)
+	unknown = protowire.AppendTag(unknown, 2, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 1)
+	proto.MessageReflect(ietf).SetUnknown(unknown)
+
+	systemID := "router1"
+	b, err := proto.Marshal(&telemetry_top.TelemetryStream{
+		SystemId: &systemID,
+		Ietf:     ietf,
+	})
+	assert.NoError(t, err)
+
+	data, err := decoder.Decode(b)
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+	assert.Equal(t, "oc-interface", data[0].DeviceInfo.Type)
+	assert.Equal(t, "router1", data[0].DeviceInfo.Context["hostname"])
+	assert.Len(t, data[0].Readings, 1)
+}
+
+func TestJuniperJTIDecoder_Decode_IETFNotOpenConfig(t *testing.T) {
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
+
+	oc, err := proto.Marshal(&opencfg.OpenConfigData{
+		Path: "/interfaces/interface[name='et-0/0/0']/",
+		Kv: []*opencfg.KeyValue{
+			{Key: "state/counters/in-octets", Value: &opencfg.KeyValue_UintValue{UintValue: 100}},
+		},
+	})
+	assert.NoError(t, err)
+
+	// A payload which parses as OpenConfig data, but whose keys are not paths.
+	other, err := proto.Marshal(&opencfg.OpenConfigData{
+		Kv: []*opencfg.KeyValue{
+			{Key: "in-octets", Value: &opencfg.KeyValue_UintValue{UintValue: 100}},
+		},
+	})
+	assert.NoError(t, err)
+
+	// Only the OpenConfig branch is decoded, and only if it holds OpenConfig data.
+	ietf := &telemetry_top.IETFSensors{}
+	unknown := protowire.AppendTag(nil, 2, protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, oc)
+	unknown = protowire.AppendTag(unknown, openConfigField, protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, other)
+	proto.MessageReflect(ietf).SetUnknown(unknown)
+
+	systemID := "router1"
+	b, err := proto.Marshal(&telemetry_top.TelemetryStream{
+		SystemId: &systemID,
+		Ietf:     ietf,
+	})
+	assert.NoError(t, err)
+
+	data, err := decoder.Decode(b)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func Test_isOpenConfigData(t *testing.T) {
+	assert.True(t, isOpenConfigData(&opencfg.OpenConfigData{
+		Kv: []*opencfg.KeyValue{{Key: "/interfaces/interface[name='et-0/0/0']/state/oper-status"}},
+	}))
+	assert.True(t, isOpenConfigData(&opencfg.OpenConfigData{
+		Path: "/interfaces/",
+		Kv:   []*opencfg.KeyValue{{Key: "interface[name='et-0/0/0']/state/oper-status"}},
+	}))
+	assert.False(t, isOpenConfigData(&opencfg.OpenConfigData{}))
+	assert.False(t, isOpenConfigData(&opencfg.OpenConfigData{
+		Kv: []*opencfg.KeyValue{{Key: "/interfaces/interface"}, {Key: "oper-status"}},
+	}))
+}
+
+func TestJuniperJTIDecoder_Decode_StreamInfo(t *testing.T) {
+	decoder := NewJTIDecoder(&config.ServerConfig{StreamTags: true}, manager.NewStubDeviceManager(false))
+
+	data, err := decoder.Decode(makeStream(t, "re0-router1:10.1.1.1", "sub:/junos/system/linecard/interface/:PFE", map[*protoimpl.ExtensionInfo]interface{}{
+		port.E_JnprInterfaceExt: makePort("et-0/0/0"),
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+	assert.Equal(t, map[string]string{
+		"interface_name": "et-0/0/0",
+		"system_id":      "re0-router1:10.1.1.1",
+		"metric_type":    "network",
+		"parent_ae_name": "",
+		"media":          "et",
+		"fpc":            "0",
+		"pic":            "0",
+		"port":           "0",
+		"hostname":       "router1",
+		"management_ip":  "10.1.1.1",
+		"routing_engine": "re0",
+		"subscription":   "sub",
+		"sensor_path":    "/junos/system/linecard/interface/",
+		"producer":       "PFE",
+	}, data[0].DeviceInfo.Context)
+	assert.Equal(t, []string{
+		"vapor/networking:interface",
+		"jti/hostname:router1",
+		"jti/management_ip:10.1.1.1",
+		"jti/producer:PFE",
+		"jti/routing_engine:re0",
+		"jti/subscription:sub",
+	}, data[0].DeviceInfo.Tags)
+}
+
+func TestJuniperJTIDecoder_Decode_Filters(t *testing.T) {
+	cfg := &config.ServerConfig{
+		Filters: config.FilterConfig{
+			SystemID: config.Filter{Exclude: []string{"lab-*"}},
+			Sensor:   config.Filter{Include: []string{"/interface/"}},
+			Interface: config.Filter{
+				Exclude: []string{"*.local", "pfh-*", "em0"},
+			},
+			Metric: config.Filter{
+				Include: []string{"if_octets", "/^if_1sec_/"},
+			},
+		},
+	}
+	assert.NoError(t, cfg.Filters.Compile())
+	decoder := NewJTIDecoder(cfg, manager.NewStubDeviceManager(false))
+
+	// Filtered by system ID.
+	data, err := decoder.Decode(makeStream(t, "lab-router1", "sensor:/junos/system/linecard/interface/", map[*protoimpl.ExtensionInfo]interface{}{
+		port.E_JnprInterfaceExt: makePort("et-0/0/0"),
+	}))
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	// Filtered by sensor.
+	data, err = decoder.Decode(makeStream(t, "router1", "sensor:/junos/system/linecard/optics/", map[*protoimpl.ExtensionInfo]interface{}{
+		port.E_JnprInterfaceExt: makePort("et-0/0/0"),
+	}))
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	// Filtered by interface and metric.
+	filteredInterfaces := testutil.ToFloat64(metrics.Filtered.WithLabelValues("interface"))
+	data, err = decoder.Decode(makeStream(t, "router1", "sensor:/junos/system/linecard/interface/", map[*protoimpl.ExtensionInfo]interface{}{
+		port.E_JnprInterfaceExt: makePort("et-0/0/0", "lo0.local", "pfh-0/0/0", "em0"),
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.Filtered.WithLabelValues("interface"))-filteredInterfaces)
+	assert.Equal(t, "et-0/0/0", data[0].DeviceInfo.Context["interface_name"])
+	assert.Len(t, data[0].Readings, 6)
+	for _, r := range data[0].Readings {
+		assert.Contains(t, []string{"if_octets", "if_1sec_octets", "if_1sec_pkts"}, r.Context["metric"])
+	}
+}
+
+// makeBenchmarkPort creates port data for the given number of interfaces, each with
+// traffic statistics and eight egress queues, similar to the data streamed by a large
+// router.
+func makeBenchmarkPort(interfaces int) *port.Port {
+	p := &port.Port{}
+	for i := 0; i < interfaces; i++ {
+		stats := func() *port.InterfaceStats {
+			return &port.InterfaceStats{
+				IfPkts:        proto.Uint64(uint64(1000000 + i)),
+				IfOctets:      proto.Uint64(uint64(900000000 + i)),
+				If_1SecPkts:   proto.Uint64(uint64(10000 + i)),
+				If_1SecOctets: proto.Uint64(uint64(9000000 + i)),
+				IfUcPkts:      proto.Uint64(uint64(990000 + i)),
+				IfMcPkts:      proto.Uint64(uint64(5000 + i)),
+				IfBcPkts:      proto.Uint64(uint64(5000 + i)),
+			}
+		}
+
+		info := &port.InterfaceInfos{
+			IfName:        proto.String(fmt.Sprintf("et-0/0/%d", i)),
+			SnmpIfIndex:   proto.Uint32(uint32(500 + i)),
+			InitTime:      proto.Uint64(1588000000),
+			IfHighSpeed:   proto.Uint32(100000),
+			IngressStats:  stats(),
+			EgressStats:   stats(),
+			IngressErrors: &port.IngressInterfaceErrors{IfErrors: proto.Uint64(300)},
+		}
+		for q := 0; q < 8; q++ {
+			info.EgressQueueInfo = append(info.EgressQueueInfo, &port.QueueStats{
+				QueueNumber: proto.Uint32(uint32(q)),
+				Packets:     proto.Uint64(uint64(100000 + q)),
+				Bytes:       proto.Uint64(uint64(90000000 + q)),
+			})
+		}
+		p.InterfaceStats = append(p.InterfaceStats, info)
+	}
+	return p
+}
+
+func BenchmarkJuniperJTIDecoder_Decode_Port(b *testing.B) {
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
+	data := makeStream(b, "router1", "sensor", map[*protoimpl.ExtensionInfo]interface{}{
+		port.E_JnprInterfaceExt: makeBenchmarkPort(64),
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decoder.Decode(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestJuniperJTIDecoder_Decode_FiltersBeforeTrackers(t *testing.T) {
+	cfg := &config.ServerConfig{
+		Filters: config.FilterConfig{
+			Interface: config.Filter{Exclude: []string{"pfh-*"}},
+			Metric:    config.Filter{Exclude: []string{"if_octets"}},
+		},
+	}
+	assert.NoError(t, cfg.Filters.Compile())
+	decoder := NewJTIDecoder(cfg, manager.NewStubDeviceManager(false))
+	filteredMetrics := testutil.ToFloat64(metrics.Filtered.WithLabelValues("metric"))
+
+	p := &port.Port{
+		InterfaceStats: []*port.InterfaceInfos{
+			makeMember("et-0/0/0", "ae0", "UP", 100000, 1),
+			makeMember("pfh-0/0/0", "ae0", "UP", 100000, 1),
+		},
+	}
+	data, err := decoder.Decode(makeStream(t, "router1", "sensor", map[*protoimpl.ExtensionInfo]interface{}{
+		port.E_JnprInterfaceExt: p,
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, data, 2, "the allowed interface and its bundle")
+
+	// Filtered interfaces are not recorded, and are not members of their bundle.
+	assert.NotNil(t, decoder.interfaces.Lookup("router1", 0, "et-0/0/0"))
+	assert.Nil(t, decoder.interfaces.Lookup("router1", 0, "pfh-0/0/0"))
+	assert.Len(t, decoder.bundles.Members("router1", "ae0"), 1)
+
+	// Readings for filtered metrics are not built for the interface or its bundle,
+	// and are counted once each.
+	for _, d := range data {
+		for _, r := range d.Readings {
+			assert.NotEqual(t, "if_octets", r.Context["metric"])
+		}
+	}
+	assert.Equal(t, float64(4), testutil.ToFloat64(metrics.Filtered.WithLabelValues("metric"))-filteredMetrics)
+}
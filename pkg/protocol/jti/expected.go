@@ -0,0 +1,50 @@
+package jti
+
+import (
+	"errors"
+	"fmt"
+
+	cfg "github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+)
+
+// MakeRouterDeviceInfo creates a DeviceInfo for a router, identified by the system ID
+// it streams data with. Router devices are only created for the routers which are
+// expected by the configuration, so that a router which does not stream any data is
+// still represented by a device.
+func MakeRouterDeviceInfo(systemID string) (*DeviceInfo, error) {
+	if systemID == "" {
+		return nil, errors.New("unable to load router device info: no system ID")
+	}
+
+	return &DeviceInfo{
+		Type: "router",
+		Info: fmt.Sprintf("%s router", systemID),
+		Tags: []string{
+			"vapor/networking:router",
+		},
+		Context: map[string]string{
+			"system_id":   systemID,
+			"metric_type": "network",
+		},
+		IDComponents: map[string]string{
+			"sys": systemID,
+		},
+	}, nil
+}
+
+// MakeExpectedInterfaceDeviceInfo creates a DeviceInfo for an interface which is expected
+// to be reported by a router. This is the DeviceInfo that is made when the interface
+// is first reported, so the device for an expected interface has the same ID as the
+// device for its data.
+func MakeExpectedInterfaceDeviceInfo(systemID string, iface cfg.ExpectedInterface) (*DeviceInfo, error) {
+	ctx := &PortContext{
+		SystemID:       systemID,
+		ComponentID:    iface.ComponentID,
+		SubComponentID: iface.SubComponentID,
+	}
+	name := iface.Name
+	return ctx.MakeDeviceInfo(&port.InterfaceInfos{
+		IfName: &name,
+	})
+}
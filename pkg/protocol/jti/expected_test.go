@@ -0,0 +1,61 @@
+package jti
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cfg "github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+)
+
+func TestMakeRouterDeviceInfo(t *testing.T) {
+	info, err := MakeRouterDeviceInfo("router1:10.1.1.1")
+	assert.NoError(t, err)
+	assert.Equal(t, &DeviceInfo{
+		Type: "router",
+		Info: "router1:10.1.1.1 router",
+		Tags: []string{"vapor/networking:router"},
+		Context: map[string]string{
+			"system_id":   "router1:10.1.1.1",
+			"metric_type": "network",
+		},
+		IDComponents: map[string]string{
+			"sys": "router1:10.1.1.1",
+		},
+	}, info)
+}
+
+func TestMakeRouterDeviceInfo_ErrNoSystemID(t *testing.T) {
+	info, err := MakeRouterDeviceInfo("")
+	assert.Error(t, err)
+	assert.Nil(t, info)
+}
+
+func TestMakeExpectedInterfaceDeviceInfo(t *testing.T) {
+	info, err := MakeExpectedInterfaceDeviceInfo("router1:10.1.1.1", cfg.ExpectedInterface{
+		Name:           "xe-0/0/1",
+		ComponentID:    1,
+		SubComponentID: 2,
+	})
+	assert.NoError(t, err)
+
+	// The expected interface is identified the same way as the interface is when it
+	// is reported.
+	name := "xe-0/0/1"
+	ctx := &PortContext{SystemID: "router1:10.1.1.1", ComponentID: 1, SubComponentID: 2}
+	reported, err := ctx.MakeDeviceInfo(&port.InterfaceInfos{IfName: &name})
+	assert.NoError(t, err)
+	assert.Equal(t, reported, info)
+	assert.Equal(t, map[string]string{
+		"sys":  "router1:10.1.1.1",
+		"if":   "xe-0/0/1",
+		"cid":  "1",
+		"scid": "2",
+	}, info.IDComponents)
+}
+
+func TestMakeExpectedInterfaceDeviceInfo_ErrNoName(t *testing.T) {
+	info, err := MakeExpectedInterfaceDeviceInfo("router1:10.1.1.1", cfg.ExpectedInterface{})
+	assert.Error(t, err)
+	assert.Nil(t, info)
+}
@@ -0,0 +1,198 @@
+package jti
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+// flapEvent records a number of interface transitions observed at a point in time.
+type flapEvent struct {
+	at    time.Time
+	count uint64
+}
+
+// flapState holds the transition history for a single interface.
+type flapState struct {
+	transitions    uint64
+	hasTransitions bool
+	operStatus     string
+	lastChange     uint32
+	events         []flapEvent
+	seen           time.Time
+}
+
+// flapTracker tracks interface transitions across samples in order to detect
+// link flaps over sliding windows.
+//
+// Transitions are taken from the if_transitions counter reported by the interface.
+// If an interface does not report transitions, a change in its operational status
+// or ifLastChange between samples is counted as a transition instead.
+//
+// The state of an interface which has no transitions within the longest window, and
+// which has not been reported for longer than the longest window, is evicted, so that
+// the state of interfaces which no longer exist is not held indefinitely.
+//
+// A flapTracker is safe for concurrent use.
+type flapTracker struct {
+	mu      sync.Mutex
+	windows []config.FlapWindow
+	states  map[string]*flapState
+
+	// labels are the formatted windows, which the readings for each window are
+	// labeled by.
+	labels []string
+
+	// longest is the duration of the longest window, and swept is when states were
+	// last checked for eviction. States are checked at most once per longest window.
+	longest time.Duration
+	swept   time.Time
+
+	// now gets the current time. It is defined on the tracker so it may be
+	// overridden for testing.
+	now func() time.Time
+}
+
+// newFlapTracker creates a new flapTracker for the given windows.
+func newFlapTracker(windows []config.FlapWindow) *flapTracker {
+	var longest time.Duration
+	labels := make([]string, len(windows))
+	for i, w := range windows {
+		if w.Window > longest {
+			longest = w.Window
+		}
+		labels[i] = formatWindow(w.Window)
+	}
+	return &flapTracker{
+		windows: windows,
+		states:  make(map[string]*flapState),
+		labels:  labels,
+		longest: longest,
+		now:     time.Now,
+	}
+}
+
+// Update the tracker with the latest data for an interface, returning the number of
+// transitions within each of the tracker's windows.
+func (tracker *flapTracker) Update(key string, iface *port.InterfaceInfos) []uint64 {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	now := tracker.now()
+
+	state, exists := tracker.states[key]
+	if !exists {
+		state = &flapState{}
+		tracker.states[key] = state
+	}
+
+	var count uint64
+	if iface.IfTransitions != nil {
+		transitions := iface.GetIfTransitions()
+		if state.hasTransitions {
+			if transitions >= state.transitions {
+				count = transitions - state.transitions
+			} else {
+				// The counter was reset, e.g. by clearing interface statistics. All
+				// transitions since the reset are new.
+				count = transitions
+			}
+		}
+		state.transitions = transitions
+		state.hasTransitions = true
+	} else if exists {
+		if iface.GetIfOperationalStatus() != state.operStatus || iface.GetIfLastChange() != state.lastChange {
+			count = 1
+		}
+	}
+	state.operStatus = iface.GetIfOperationalStatus()
+	state.lastChange = iface.GetIfLastChange()
+
+	if count > 0 {
+		state.events = append(state.events, flapEvent{at: now, count: count})
+	}
+
+	state.seen = now
+
+	// Drop events which fall outside of the longest window.
+	state.events = dropExpired(state.events, now.Add(-tracker.longest))
+	tracker.evict(now)
+
+	counts := make([]uint64, len(tracker.windows))
+	for idx, w := range tracker.windows {
+		start := now.Add(-w.Window)
+		for _, e := range state.events {
+			if e.at.After(start) {
+				counts[idx] += e.count
+			}
+		}
+	}
+	return counts
+}
+
+// evict the states of interfaces which have not been reported, and have had no
+// transitions, within the longest window. The caller must hold mu.
+func (tracker *flapTracker) evict(now time.Time) {
+	if now.Sub(tracker.swept) < tracker.longest {
+		return
+	}
+	tracker.swept = now
+
+	cutoff := now.Add(-tracker.longest)
+	for key, state := range tracker.states {
+		if len(dropExpired(state.events, cutoff)) == 0 && !state.seen.After(cutoff) {
+			delete(tracker.states, key)
+		}
+	}
+}
+
+// dropExpired drops the events which occurred at or before the cutoff.
+func dropExpired(events []flapEvent, cutoff time.Time) []flapEvent {
+	i := 0
+	for i < len(events) && !events[i].at.After(cutoff) {
+		i++
+	}
+	return events[i:]
+}
+
+// makeFlapReadings adds the link flap readings for an interface to its set of readings:
+// the number of transitions within each window and whether the interface is flapping.
+func (ctx *PortContext) makeFlapReadings(set *readingSet, iface *port.InterfaceInfos) {
+	if ctx.flaps == nil {
+		return
+	}
+
+	key := ctx.SystemID + "/" + iface.GetIfName()
+	counts := ctx.flaps.Update(key, iface)
+
+	flapping := false
+	for i, w := range ctx.flaps.windows {
+		if counts[i] > uint64(w.Threshold) {
+			flapping = true
+		}
+		set.add(&output.Number, counts[i], windowContext(ctx.flaps.labels[i], "if_flaps"))
+	}
+
+	status := "stable"
+	if flapping {
+		status = "flapping"
+	}
+	set.add(&output.Status, status, metricContext("if_flap_status"))
+}
+
+// formatWindow formats a window duration without trailing zero units, e.g. a
+// window of 5 minutes is formatted as "5m" rather than "5m0s".
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
@@ -0,0 +1,179 @@
+package jti
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+)
+
+var testFlapWindows = []config.FlapWindow{
+	{Window: 5 * time.Minute, Threshold: 2},
+	{Window: 1 * time.Hour, Threshold: 10},
+}
+
+// testClock is a settable clock for trackers which depend on the current time.
+type testClock struct {
+	t time.Time
+}
+
+func (c *testClock) now() time.Time {
+	return c.t
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestClock() *testClock {
+	return &testClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func withTransitions(transitions uint64) *port.InterfaceInfos {
+	return &port.InterfaceInfos{
+		IfName:        &stringVal,
+		IfTransitions: &transitions,
+	}
+}
+
+func withStatus(status string, lastChange uint32) *port.InterfaceInfos {
+	return &port.InterfaceInfos{
+		IfName:              &stringVal,
+		IfOperationalStatus: &status,
+		IfLastChange:        &lastChange,
+	}
+}
+
+func TestFlapTracker_Update_Transitions(t *testing.T) {
+	clock := newTestClock()
+	tracker := newFlapTracker(testFlapWindows)
+	tracker.now = clock.now
+
+	// The first sample establishes the baseline.
+	assert.Equal(t, []uint64{0, 0}, tracker.Update("a", withTransitions(10)))
+
+	clock.advance(time.Minute)
+	assert.Equal(t, []uint64{2, 2}, tracker.Update("a", withTransitions(12)))
+
+	clock.advance(time.Minute)
+	assert.Equal(t, []uint64{3, 3}, tracker.Update("a", withTransitions(13)))
+
+	// Events age out of the shorter window, but not the longer one.
+	clock.advance(10 * time.Minute)
+	assert.Equal(t, []uint64{0, 3}, tracker.Update("a", withTransitions(13)))
+
+	// And eventually out of the longer window.
+	clock.advance(time.Hour)
+	assert.Equal(t, []uint64{0, 0}, tracker.Update("a", withTransitions(13)))
+	assert.Empty(t, tracker.states["a"].events)
+}
+
+func TestFlapTracker_Update_CounterReset(t *testing.T) {
+	clock := newTestClock()
+	tracker := newFlapTracker(testFlapWindows)
+	tracker.now = clock.now
+
+	tracker.Update("a", withTransitions(10))
+	clock.advance(time.Minute)
+	assert.Equal(t, []uint64{1, 1}, tracker.Update("a", withTransitions(1)))
+}
+
+func TestFlapTracker_Update_Fallback(t *testing.T) {
+	clock := newTestClock()
+	tracker := newFlapTracker(testFlapWindows)
+	tracker.now = clock.now
+
+	assert.Equal(t, []uint64{0, 0}, tracker.Update("a", withStatus("UP", 100)))
+
+	clock.advance(time.Second)
+	assert.Equal(t, []uint64{0, 0}, tracker.Update("a", withStatus("UP", 100)))
+
+	// Oper status changed.
+	clock.advance(time.Second)
+	assert.Equal(t, []uint64{1, 1}, tracker.Update("a", withStatus("DOWN", 200)))
+
+	// Last change time changed, even though the status is the same as
+	// it was in the previous sample.
+	clock.advance(time.Second)
+	assert.Equal(t, []uint64{2, 2}, tracker.Update("a", withStatus("DOWN", 300)))
+}
+
+func TestFlapTracker_Update_SeparateKeys(t *testing.T) {
+	tracker := newFlapTracker(testFlapWindows)
+
+	tracker.Update("a", withTransitions(1))
+	tracker.Update("b", withTransitions(5))
+	assert.Equal(t, []uint64{1, 1}, tracker.Update("a", withTransitions(2)))
+	assert.Equal(t, []uint64{0, 0}, tracker.Update("b", withTransitions(5)))
+}
+
+func TestPortContext_makeFlapReadings(t *testing.T) {
+	clock := newTestClock()
+	ctx := PortContext{
+		SystemID: "test",
+		flaps:    newFlapTracker(testFlapWindows),
+	}
+	ctx.flaps.now = clock.now
+
+	set := newReadingSet(3, nil)
+	ctx.makeFlapReadings(set, withTransitions(0))
+	readings := set.Readings()
+	assert.Len(t, readings, 3)
+	assert.Equal(t, "5m", readings[0].Context["window"])
+	assert.Equal(t, "1h", readings[1].Context["window"])
+	assert.Equal(t, "stable", readings[2].Value)
+
+	clock.advance(time.Minute)
+	set = newReadingSet(3, nil)
+	ctx.makeFlapReadings(set, withTransitions(3))
+	readings = set.Readings()
+	assert.Equal(t, uint64(3), readings[0].Value)
+	assert.Equal(t, uint64(3), readings[1].Value)
+	assert.Equal(t, "flapping", readings[2].Value)
+
+	// The contexts are shared by the readings of all interfaces.
+	assert.Equal(t, fmt.Sprintf("%p", windowContext("5m", "if_flaps")), fmt.Sprintf("%p", readings[0].Context))
+}
+
+func TestPortContext_makeFlapReadings_NilTracker(t *testing.T) {
+	ctx := PortContext{SystemID: "test"}
+	set := newReadingSet(0, nil)
+	ctx.makeFlapReadings(set, withTransitions(0))
+	assert.Empty(t, set.Readings())
+}
+
+func Test_formatWindow(t *testing.T) {
+	assert.Equal(t, "30s", formatWindow(30*time.Second))
+	assert.Equal(t, "5m", formatWindow(5*time.Minute))
+	assert.Equal(t, "5m30s", formatWindow(5*time.Minute+30*time.Second))
+	assert.Equal(t, "1h", formatWindow(time.Hour))
+	assert.Equal(t, "1h30m", formatWindow(90*time.Minute))
+}
+
+func TestFlapTracker_Update_Evicts(t *testing.T) {
+	clock := newTestClock()
+	tracker := newFlapTracker(testFlapWindows)
+	tracker.now = clock.now
+
+	tracker.Update("gone", withTransitions(1))
+	tracker.Update("flapped", withTransitions(1))
+	clock.advance(30 * time.Minute)
+	tracker.Update("flapped", withTransitions(3))
+	tracker.Update("active", withTransitions(1))
+
+	// States which are not reported, and have no transitions, within the longest
+	// window are evicted. States with recent transitions are kept.
+	clock.advance(45 * time.Minute)
+	tracker.Update("active", withTransitions(1))
+	assert.Contains(t, tracker.states, "active")
+	assert.Contains(t, tracker.states, "flapped")
+	assert.NotContains(t, tracker.states, "gone")
+
+	clock.advance(time.Hour)
+	tracker.Update("active", withTransitions(1))
+	assert.Contains(t, tracker.states, "active")
+	assert.NotContains(t, tracker.states, "flapped")
+}
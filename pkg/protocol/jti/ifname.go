@@ -0,0 +1,100 @@
+package jti
+
+import (
+	"regexp"
+)
+
+var (
+	// physicalInterface matches the name of a physical interface, which encodes its
+	// location in the chassis, e.g. "et-2/1/3:1.0" is the 100GbE interface on FPC 2,
+	// PIC 1, port 3, channel 1, unit 0.
+	physicalInterface = regexp.MustCompile(`^([a-z]+)-(\d+)/(\d+)/(\d+)(?::(\d+))?(?:\.(\d+))?$`)
+
+	// logicalInterface matches the name of an interface which is not associated with
+	// a chassis location, e.g. "ae0", "lo0.0", "fxp0", or "irb.100".
+	logicalInterface = regexp.MustCompile(`^([a-z]+)(\d*)(?:\.(\d+))?$`)
+)
+
+// logicalMedia are the media types of Junos interfaces which are not associated with a
+// chassis location. Names matching the logical interface format are only recognized if
+// they have one of these media types, since the format alone matches most words.
+var logicalMedia = map[string]bool{
+	"ae": true, "as": true, "bme": true, "cbp": true, "demux": true, "dsc": true,
+	"em": true, "esi": true, "fti": true, "fxp": true, "gr": true, "gre": true,
+	"ip": true, "ipip": true, "irb": true, "jsrv": true, "lo": true, "lsi": true,
+	"me": true, "mt": true, "mtun": true, "pd": true, "pe": true, "pimd": true,
+	"pime": true, "pip": true, "pp": true, "rbeb": true, "st": true, "tap": true,
+	"vlan": true, "vme": true, "vtep": true,
+}
+
+// InterfaceName holds the components of a Junos interface name.
+//
+// Physical interface names are formatted as "<media>-<fpc>/<pic>/<port>[:<channel>][.<unit>]".
+// Other interfaces are named by their media type and an optional index and unit, e.g.
+// "ae0.0". Components which are not present in a name are left empty.
+//
+// See: https://www.juniper.net/documentation/en_US/junos/topics/concept/interfaces-interface-naming-overview.html
+type InterfaceName struct {
+	Media   string
+	FPC     string
+	PIC     string
+	Port    string
+	Channel string
+	Unit    string
+}
+
+// ParseInterfaceName parses a Junos interface name into its components. If the
+// name is not a recognized interface name, false is returned.
+func ParseInterfaceName(name string) (*InterfaceName, bool) {
+	if m := physicalInterface.FindStringSubmatch(name); m != nil {
+		return &InterfaceName{
+			Media:   m[1],
+			FPC:     m[2],
+			PIC:     m[3],
+			Port:    m[4],
+			Channel: m[5],
+			Unit:    m[6],
+		}, true
+	}
+
+	if m := logicalInterface.FindStringSubmatch(name); m != nil && logicalMedia[m[1]] {
+		return &InterfaceName{
+			Media: m[1],
+			Unit:  m[3],
+		}, true
+	}
+
+	return nil, false
+}
+
+// Context gets the device context for the interface name components. Only the
+// components which are present are included.
+func (name *InterfaceName) Context() map[string]string {
+	ctx := map[string]string{}
+	for k, v := range map[string]string{
+		"media":   name.Media,
+		"fpc":     name.FPC,
+		"pic":     name.PIC,
+		"port":    name.Port,
+		"channel": name.Channel,
+		"unit":    name.Unit,
+	} {
+		if v != "" {
+			ctx[k] = v
+		}
+	}
+	return ctx
+}
+
+// addInterfaceContext adds the components parsed from an interface name to a device
+// context. If the interface name is not recognized, the context is left unchanged.
+func addInterfaceContext(ctx map[string]string, ifaceName string) map[string]string {
+	name, ok := ParseInterfaceName(ifaceName)
+	if !ok {
+		return ctx
+	}
+	for k, v := range name.Context() {
+		ctx[k] = v
+	}
+	return ctx
+}
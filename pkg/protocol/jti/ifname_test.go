@@ -0,0 +1,87 @@
+package jti
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterfaceName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected *InterfaceName
+	}{
+		{"ge-0/0/0", &InterfaceName{Media: "ge", FPC: "0", PIC: "0", Port: "0"}},
+		{"ge-1/2/3.0", &InterfaceName{Media: "ge", FPC: "1", PIC: "2", Port: "3", Unit: "0"}},
+		{"xe-0/0/0", &InterfaceName{Media: "xe", FPC: "0", PIC: "0", Port: "0"}},
+		{"xe-10/1/15.100", &InterfaceName{Media: "xe", FPC: "10", PIC: "1", Port: "15", Unit: "100"}},
+		{"et-2/1/3", &InterfaceName{Media: "et", FPC: "2", PIC: "1", Port: "3"}},
+		{"et-2/1/3:1", &InterfaceName{Media: "et", FPC: "2", PIC: "1", Port: "3", Channel: "1"}},
+		{"et-2/1/3:1.32767", &InterfaceName{Media: "et", FPC: "2", PIC: "1", Port: "3", Channel: "1", Unit: "32767"}},
+		{"pfh-0/0/0.16383", &InterfaceName{Media: "pfh", FPC: "0", PIC: "0", Port: "0", Unit: "16383"}},
+		{"ae0", &InterfaceName{Media: "ae"}},
+		{"ae12.0", &InterfaceName{Media: "ae", Unit: "0"}},
+		{"irb", &InterfaceName{Media: "irb"}},
+		{"irb.100", &InterfaceName{Media: "irb", Unit: "100"}},
+		{"lo0", &InterfaceName{Media: "lo"}},
+		{"lo0.16384", &InterfaceName{Media: "lo", Unit: "16384"}},
+		{"fxp0", &InterfaceName{Media: "fxp"}},
+		{"fxp0.0", &InterfaceName{Media: "fxp", Unit: "0"}},
+		{"em0", &InterfaceName{Media: "em"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name, ok := ParseInterfaceName(test.name)
+			assert.True(t, ok)
+			assert.Equal(t, test.expected, name)
+		})
+	}
+}
+
+func TestParseInterfaceName_Unrecognized(t *testing.T) {
+	for _, name := range []string{
+		"",
+		"string",
+		"et-0/0",
+		"et-0/0/0/0",
+		"et-a/b/c",
+		"ET-0/0/0",
+		"xe-0/0/0:",
+		"ae0.",
+		".local",
+	} {
+		t.Run(name, func(t *testing.T) {
+			parsed, ok := ParseInterfaceName(name)
+			assert.False(t, ok)
+			assert.Nil(t, parsed)
+		})
+	}
+}
+
+func TestInterfaceName_Context(t *testing.T) {
+	name := InterfaceName{Media: "et", FPC: "2", PIC: "1", Port: "3", Channel: "1"}
+	assert.Equal(t, map[string]string{
+		"media":   "et",
+		"fpc":     "2",
+		"pic":     "1",
+		"port":    "3",
+		"channel": "1",
+	}, name.Context())
+}
+
+func Test_addInterfaceContext(t *testing.T) {
+	ctx := addInterfaceContext(map[string]string{"interface_name": "irb.100"}, "irb.100")
+	assert.Equal(t, map[string]string{
+		"interface_name": "irb.100",
+		"media":          "irb",
+		"unit":           "100",
+	}, ctx)
+}
+
+func Test_addInterfaceContext_Unrecognized(t *testing.T) {
+	ctx := addInterfaceContext(map[string]string{"interface_name": "foo"}, "foo")
+	assert.Equal(t, map[string]string{
+		"interface_name": "foo",
+	}, ctx)
+}
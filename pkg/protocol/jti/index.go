@@ -0,0 +1,73 @@
+package jti
+
+import (
+	"sync"
+)
+
+// interfaceKey identifies an interface on a given system, either by its SNMP
+// interface index or by its name.
+type interfaceKey struct {
+	systemID string
+	index    uint32
+	name     string
+}
+
+// interfaceIndex tracks the interface devices found in port messages so that devices
+// from other sensors (e.g. optics) can be joined to the interface device they belong
+// to. Interfaces are joined by their SNMP interface index, which is reported by
+// multiple sensors, falling back to the interface name.
+//
+// An interfaceIndex is safe for concurrent use.
+type interfaceIndex struct {
+	mu         sync.RWMutex
+	interfaces map[interfaceKey]*DeviceInfo
+}
+
+// newInterfaceIndex creates a new interfaceIndex.
+func newInterfaceIndex() *interfaceIndex {
+	return &interfaceIndex{
+		interfaces: make(map[interfaceKey]*DeviceInfo),
+	}
+}
+
+// Record the DeviceInfo for an interface with the given name and SNMP interface index.
+// An index of zero is treated as not reported.
+//
+// Only the information needed to identify the interface device is recorded, so the
+// recorded DeviceInfo is not affected by any later changes to the given DeviceInfo.
+func (idx *interfaceIndex) Record(systemID string, snmpIndex uint32, name string, info *DeviceInfo) {
+	components := make(map[string]string, len(info.IDComponents))
+	for k, v := range info.IDComponents {
+		components[k] = v
+	}
+	recorded := &DeviceInfo{
+		Type:         info.Type,
+		IDComponents: components,
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if snmpIndex != 0 {
+		idx.interfaces[interfaceKey{systemID: systemID, index: snmpIndex}] = recorded
+	}
+	idx.interfaces[interfaceKey{systemID: systemID, name: name}] = recorded
+}
+
+// Lookup the DeviceInfo for an interface by its SNMP interface index, falling back
+// to the interface name. If no interface is found, nil is returned.
+func (idx *interfaceIndex) Lookup(systemID string, snmpIndex uint32, name string) *DeviceInfo {
+	if idx == nil {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if snmpIndex != 0 {
+		if info, ok := idx.interfaces[interfaceKey{systemID: systemID, index: snmpIndex}]; ok {
+			return info
+		}
+	}
+	return idx.interfaces[interfaceKey{systemID: systemID, name: name}]
+}
@@ -0,0 +1,49 @@
+package jti
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterfaceIndex_Lookup(t *testing.T) {
+	index := newInterfaceIndex()
+	info := &DeviceInfo{
+		Type:         "interface",
+		Info:         "test interface et-0/0/0",
+		Context:      map[string]string{"interface_name": "et-0/0/0"},
+		IDComponents: map[string]string{"sys": "test", "if": "et-0/0/0"},
+	}
+	index.Record("test", 520, "et-0/0/0", info)
+
+	// Only the identifying information is recorded, and later changes to the
+	// recorded info do not affect it.
+	info.IDComponents["if"] = "changed"
+	expected := &DeviceInfo{
+		Type:         "interface",
+		IDComponents: map[string]string{"sys": "test", "if": "et-0/0/0"},
+	}
+
+	// By index, regardless of name.
+	assert.Equal(t, expected, index.Lookup("test", 520, "other"))
+
+	// By name, when no index is given or the index is not known.
+	assert.Equal(t, expected, index.Lookup("test", 0, "et-0/0/0"))
+	assert.Equal(t, expected, index.Lookup("test", 1, "et-0/0/0"))
+
+	// Interfaces are scoped by system ID.
+	assert.Nil(t, index.Lookup("other", 520, "et-0/0/0"))
+}
+
+func TestInterfaceIndex_Lookup_NoIndex(t *testing.T) {
+	index := newInterfaceIndex()
+	index.Record("test", 0, "et-0/0/0", &DeviceInfo{Type: "interface"})
+
+	assert.Nil(t, index.Lookup("test", 0, "et-0/0/1"))
+	assert.NotNil(t, index.Lookup("test", 0, "et-0/0/0"))
+}
+
+func TestInterfaceIndex_Lookup_Nil(t *testing.T) {
+	var index *interfaceIndex
+	assert.Nil(t, index.Lookup("test", 520, "et-0/0/0"))
+}
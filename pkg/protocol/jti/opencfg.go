@@ -0,0 +1,290 @@
+package jti
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/outputs"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/opencfg"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+// OpenConfigContext provides contextual information used to generate devices and
+// readings from an OpenConfig key/value message.
+type OpenConfigContext struct {
+	SensorName     string
+	SystemID       string
+	ComponentID    uint32
+	SubComponentID uint32
+}
+
+// NewOpenConfigContextFromStream creates a new OpenConfigContext populated with values
+// from the higher-level TelemetryStream GPB message associated with the OpenConfig message.
+func NewOpenConfigContextFromStream(ts *telemetry_top.TelemetryStream) *OpenConfigContext {
+	return &OpenConfigContext{
+		SensorName:     ts.GetSensorName(),
+		SystemID:       ts.GetSystemId(),
+		ComponentID:    ts.GetComponentId(),
+		SubComponentID: ts.GetSubComponentId(),
+	}
+}
+
+// Decode the OpenConfigData GPB message into data containers which can be translated
+// into Synse devices and readings.
+//
+// Each leaf is mapped to the device identified by the keyed elements of its path, e.g.
+// the leaf "/interfaces/interface[name=et-0/0/0]/state/counters/in-octets" is a reading
+// for the "/interfaces/interface" device with the key "name=et-0/0/0". Leaves without
+// any keyed elements are mapped to the device identified by their parent path.
+func (ctx *OpenConfigContext) Decode(data *opencfg.OpenConfigData) ([]*IntermediaryDataContainer, error) {
+	var decoded []*IntermediaryDataContainer
+
+	if data == nil {
+		log.Info("[jti] openconfig decode: data is nil, no data to collect")
+		return decoded, nil
+	}
+
+	devices := map[string]*IntermediaryDataContainer{}
+	for _, kv := range data.GetKv() {
+		path, err := parseOpenConfigPath(joinOpenConfigPath(data.GetPath(), kv.GetKey()))
+		if err != nil {
+			log.WithFields(log.Fields{
+				"err": err,
+				"key": kv.GetKey(),
+			}).Warning("[jti] openconfig decode: failed to parse key - skipping")
+			continue
+		}
+		if len(path) < 2 {
+			log.WithField("key", kv.GetKey()).Debug("[jti] openconfig decode: key is not a leaf - skipping")
+			continue
+		}
+
+		devicePath, leaf := splitOpenConfigPath(path)
+		reading := ctx.MakeReading(leaf, kv)
+		if reading == nil {
+			continue
+		}
+
+		id := devicePath.String()
+		device, exists := devices[id]
+		if !exists {
+			deviceInfo, err := ctx.MakeDeviceInfo(devicePath)
+			if err != nil {
+				return nil, err
+			}
+			device = &IntermediaryDataContainer{
+				DeviceInfo: deviceInfo,
+			}
+			devices[id] = device
+			decoded = append(decoded, device)
+		}
+		device.Readings = append(device.Readings, reading)
+	}
+	return decoded, nil
+}
+
+// MakeDeviceInfo creates a DeviceInfo corresponding to the path of an OpenConfig
+// device. The DeviceInfo is used to generate SDK devices.
+func (ctx *OpenConfigContext) MakeDeviceInfo(path openConfigPath) (*DeviceInfo, error) {
+	if len(path) == 0 {
+		return nil, errors.New("unable to load device info from openconfig context: empty path")
+	}
+
+	if ctx.SystemID == "" {
+		return nil, errors.New("unable to load device info from openconfig context: context has no system ID")
+	}
+
+	deviceType := "oc-" + path[len(path)-1].Name
+	deviceContext := map[string]string{
+		"oc_path":     path.Schema(),
+		"system_id":   ctx.SystemID,
+		"metric_type": "network",
+	}
+	idComponents := map[string]string{
+		"sys":  ctx.SystemID,
+		"path": path.Schema(),
+		"cid":  fmt.Sprint(ctx.ComponentID),
+		"scid": fmt.Sprint(ctx.SubComponentID),
+	}
+	for _, elem := range path {
+		for _, key := range elem.Keys {
+			name := openConfigName(elem.Name + "_" + key.Name)
+			deviceContext[name] = key.Value
+			idComponents[name] = key.Value
+		}
+	}
+
+	info := &DeviceInfo{
+		Type: deviceType,
+		Info: fmt.Sprintf("%s %s", ctx.SystemID, path.String()),
+		Tags: []string{
+			"vapor/networking:" + deviceType,
+		},
+		Context:      deviceContext,
+		IDComponents: idComponents,
+	}
+	if ifaceName, ok := deviceContext["interface_name"]; ok {
+		info.Context = addInterfaceContext(info.Context, ifaceName)
+	}
+	return info, nil
+}
+
+// MakeReading creates a device reading for an OpenConfig leaf, typed by the value of
+// the leaf. Counters of octets and packets use the corresponding counter outputs.
+//
+// If the leaf has no value, or a value which can not be represented as a reading, nil
+// is returned.
+func (ctx *OpenConfigContext) MakeReading(leaf openConfigPath, kv *opencfg.KeyValue) *output.Reading {
+	name := leaf[len(leaf)-1].Name
+	readingContext := map[string]string{
+		"metric":  openConfigName(leaf.Schema()[1:]),
+		"oc_leaf": leaf.Schema(),
+	}
+
+	var value interface{}
+	switch v := kv.GetValue().(type) {
+	case *opencfg.KeyValue_DoubleValue:
+		value = v.DoubleValue
+	case *opencfg.KeyValue_IntValue:
+		value = v.IntValue
+	case *opencfg.KeyValue_UintValue:
+		value = v.UintValue
+	case *opencfg.KeyValue_SintValue:
+		value = v.SintValue
+	case *opencfg.KeyValue_BoolValue:
+		return outputs.Boolean.MakeReading(v.BoolValue).WithContext(readingContext)
+	case *opencfg.KeyValue_StrValue:
+		return output.String.MakeReading(v.StrValue).WithContext(readingContext)
+	default:
+		log.WithFields(log.Fields{
+			"key":  kv.GetKey(),
+			"type": fmt.Sprintf("%T", v),
+		}).Debug("[jti] openconfig decode: unsupported value type - skipping")
+		return nil
+	}
+
+	switch {
+	case strings.HasSuffix(name, "octets"):
+		return outputs.BytesCounter.MakeReading(value).WithContext(readingContext)
+	case strings.HasSuffix(name, "pkts"):
+		return outputs.PacketsCounter.MakeReading(value).WithContext(readingContext)
+	default:
+		return output.Number.MakeReading(value).WithContext(readingContext)
+	}
+}
+
+// openConfigKey is a key of an element of an OpenConfig path, e.g. "name=et-0/0/0".
+type openConfigKey struct {
+	Name  string
+	Value string
+}
+
+// openConfigElem is an element of an OpenConfig path, with any keys it has.
+type openConfigElem struct {
+	Name string
+	Keys []openConfigKey
+}
+
+// openConfigPath is a parsed OpenConfig path.
+type openConfigPath []openConfigElem
+
+// String gets the string representation of the path, including its keys.
+func (path openConfigPath) String() string {
+	var b strings.Builder
+	for _, elem := range path {
+		b.WriteString("/" + elem.Name)
+		for _, key := range elem.Keys {
+			b.WriteString("[" + key.Name + "=" + key.Value + "]")
+		}
+	}
+	return b.String()
+}
+
+// Schema gets the string representation of the path without its keys.
+func (path openConfigPath) Schema() string {
+	var b strings.Builder
+	for _, elem := range path {
+		b.WriteString("/" + elem.Name)
+	}
+	return b.String()
+}
+
+// joinOpenConfigPath joins the key of a leaf to the path prefix of its message. If the
+// key is an absolute path, the prefix is not used.
+func joinOpenConfigPath(prefix, key string) string {
+	if strings.HasPrefix(key, "/") || prefix == "" {
+		return key
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + key
+}
+
+// parseOpenConfigPath parses an OpenConfig path, e.g.
+// "/interfaces/interface[name='et-0/0/0']/state/counters/in-octets". Key values may be
+// quoted, and may contain slashes.
+func parseOpenConfigPath(s string) (openConfigPath, error) {
+	var path openConfigPath
+	var elem *openConfigElem
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '/':
+			i++
+			elem = nil
+
+		case '[':
+			if elem == nil {
+				return nil, fmt.Errorf("key without element at offset %d in path %q", i, s)
+			}
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated key at offset %d in path %q", i, s)
+			}
+			kv := s[i+1 : i+end]
+			eq := strings.IndexByte(kv, '=')
+			if eq <= 0 {
+				return nil, fmt.Errorf("invalid key %q in path %q", kv, s)
+			}
+			elem.Keys = append(elem.Keys, openConfigKey{
+				Name:  strings.TrimSpace(kv[:eq]),
+				Value: strings.Trim(strings.TrimSpace(kv[eq+1:]), `'"`),
+			})
+			i += end + 1
+
+		default:
+			end := strings.IndexAny(s[i:], "/[")
+			if end < 0 {
+				end = len(s) - i
+			}
+			path = append(path, openConfigElem{Name: s[i : i+end]})
+			elem = &path[len(path)-1]
+			i += end
+		}
+	}
+	return path, nil
+}
+
+// splitOpenConfigPath splits the path of a leaf into the path of the device it belongs
+// to and the path of the leaf relative to that device.
+//
+// The device is identified by the path up to and including its last keyed element. If
+// the path has no keyed elements, the device is identified by the leaf's parent path.
+func splitOpenConfigPath(path openConfigPath) (openConfigPath, openConfigPath) {
+	split := len(path) - 1
+	for i := len(path) - 2; i >= 0; i-- {
+		if len(path[i].Keys) > 0 {
+			split = i + 1
+			break
+		}
+	}
+	return path[:split], path[split:]
+}
+
+// openConfigName converts an OpenConfig path or name into a name suitable for use as a
+// context key or metric name, e.g. "state/counters/in-octets" is converted to
+// "state_counters_in_octets".
+func openConfigName(s string) string {
+	return strings.NewReplacer("/", "_", "-", "_").Replace(s)
+}
@@ -0,0 +1,219 @@
+package jti
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/outputs"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/opencfg"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+)
+
+func TestNewOpenConfigContextFromStream(t *testing.T) {
+	ctx := NewOpenConfigContextFromStream(&telemetry_top.TelemetryStream{
+		SensorName:     &stringVal,
+		SystemId:       &stringVal,
+		ComponentId:    &uint32Val,
+		SubComponentId: &uint32Val,
+	})
+	assert.NotNil(t, ctx)
+	assert.Equal(t, stringVal, ctx.SensorName)
+	assert.Equal(t, stringVal, ctx.SystemID)
+	assert.Equal(t, uint32Val, ctx.ComponentID)
+	assert.Equal(t, uint32Val, ctx.SubComponentID)
+}
+
+func TestOpenConfigContext_Decode(t *testing.T) {
+	ctx := OpenConfigContext{
+		SensorName: "sensor",
+		SystemID:   "test",
+	}
+
+	data, err := ctx.Decode(&opencfg.OpenConfigData{
+		Path: "/interfaces/interface[name='et-0/0/0']/",
+		Kv: []*opencfg.KeyValue{
+			{Key: "state/counters/in-octets", Value: &opencfg.KeyValue_UintValue{UintValue: 100}},
+			{Key: "state/counters/in-pkts", Value: &opencfg.KeyValue_UintValue{UintValue: 10}},
+			{Key: "state/oper-status", Value: &opencfg.KeyValue_StrValue{StrValue: "UP"}},
+			{Key: "/interfaces/interface[name='et-0/0/1']/state/enabled", Value: &opencfg.KeyValue_BoolValue{BoolValue: true}},
+			{Key: "state/description"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, data, 2)
+
+	assert.Equal(t, "oc-interface", data[0].DeviceInfo.Type)
+	assert.Equal(t, "et-0/0/0", data[0].DeviceInfo.Context["interface_name"])
+	assert.Len(t, data[0].Readings, 3)
+	assert.Equal(t, outputs.BytesCounter.Unit, data[0].Readings[0].Unit)
+	assert.Equal(t, uint64(100), data[0].Readings[0].Value)
+	assert.Equal(t, "state_counters_in_octets", data[0].Readings[0].Context["metric"])
+	assert.Equal(t, outputs.PacketsCounter.Unit, data[0].Readings[1].Unit)
+	assert.Equal(t, "string", data[0].Readings[2].Type)
+	assert.Equal(t, "UP", data[0].Readings[2].Value)
+
+	assert.Equal(t, "et-0/0/1", data[1].DeviceInfo.Context["interface_name"])
+	assert.Len(t, data[1].Readings, 1)
+	assert.Equal(t, true, data[1].Readings[0].Value)
+}
+
+func TestOpenConfigContext_Decode_Nil(t *testing.T) {
+	ctx := OpenConfigContext{SystemID: "test"}
+
+	data, err := ctx.Decode(nil)
+	assert.NoError(t, err)
+	assert.Len(t, data, 0)
+}
+
+func TestOpenConfigContext_Decode_ErrNoSystemID(t *testing.T) {
+	ctx := OpenConfigContext{}
+
+	data, err := ctx.Decode(&opencfg.OpenConfigData{
+		Kv: []*opencfg.KeyValue{
+			{Key: "/system/state/hostname", Value: &opencfg.KeyValue_StrValue{StrValue: "router1"}},
+		},
+	})
+	assert.Error(t, err)
+	assert.Nil(t, data)
+}
+
+func TestOpenConfigContext_MakeDeviceInfo(t *testing.T) {
+	ctx := OpenConfigContext{
+		SensorName:     "sensor",
+		SystemID:       "test",
+		ComponentID:    1,
+		SubComponentID: 2,
+	}
+	path, err := parseOpenConfigPath("/interfaces/interface[name=et-0/0/0]/subinterfaces/subinterface[index=0]")
+	assert.NoError(t, err)
+
+	info, err := ctx.MakeDeviceInfo(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "oc-subinterface", info.Type)
+	assert.Equal(t, "test /interfaces/interface[name=et-0/0/0]/subinterfaces/subinterface[index=0]", info.Info)
+	assert.Equal(t, []string{"vapor/networking:oc-subinterface"}, info.Tags)
+	assert.Equal(t, map[string]string{
+		"oc_path":            "/interfaces/interface/subinterfaces/subinterface",
+		"system_id":          "test",
+		"metric_type":        "network",
+		"interface_name":     "et-0/0/0",
+		"subinterface_index": "0",
+		"media":              "et",
+		"fpc":                "0",
+		"pic":                "0",
+		"port":               "0",
+	}, info.Context)
+	assert.Equal(t, map[string]string{
+		"sys":                "test",
+		"path":               "/interfaces/interface/subinterfaces/subinterface",
+		"interface_name":     "et-0/0/0",
+		"subinterface_index": "0",
+		"cid":                "1",
+		"scid":               "2",
+	}, info.IDComponents)
+}
+
+func TestOpenConfigContext_MakeDeviceInfo_Errors(t *testing.T) {
+	ctx := OpenConfigContext{SystemID: "test"}
+	info, err := ctx.MakeDeviceInfo(nil)
+	assert.Error(t, err)
+	assert.Nil(t, info)
+
+	ctx = OpenConfigContext{}
+	info, err = ctx.MakeDeviceInfo(openConfigPath{{Name: "system"}})
+	assert.Error(t, err)
+	assert.Nil(t, info)
+}
+
+func TestOpenConfigContext_MakeReading(t *testing.T) {
+	ctx := OpenConfigContext{SystemID: "test"}
+	leaf := openConfigPath{{Name: "state"}, {Name: "temperature"}}
+
+	cases := []struct {
+		kv       *opencfg.KeyValue
+		typ      string
+		expected interface{}
+	}{
+		{kv: &opencfg.KeyValue{Value: &opencfg.KeyValue_DoubleValue{DoubleValue: 1.5}}, typ: "number", expected: 1.5},
+		{kv: &opencfg.KeyValue{Value: &opencfg.KeyValue_IntValue{IntValue: -1}}, typ: "number", expected: int64(-1)},
+		{kv: &opencfg.KeyValue{Value: &opencfg.KeyValue_UintValue{UintValue: 1}}, typ: "number", expected: uint64(1)},
+		{kv: &opencfg.KeyValue{Value: &opencfg.KeyValue_SintValue{SintValue: -2}}, typ: "number", expected: int64(-2)},
+		{kv: &opencfg.KeyValue{Value: &opencfg.KeyValue_BoolValue{BoolValue: true}}, typ: "bool", expected: true},
+		{kv: &opencfg.KeyValue{Value: &opencfg.KeyValue_StrValue{StrValue: "ok"}}, typ: "string", expected: "ok"},
+	}
+	for _, c := range cases {
+		r := ctx.MakeReading(leaf, c.kv)
+		assert.Equal(t, c.typ, r.Type)
+		assert.Equal(t, c.expected, r.Value)
+		assert.Equal(t, map[string]string{
+			"metric":  "state_temperature",
+			"oc_leaf": "/state/temperature",
+		}, r.Context)
+	}
+
+	// Unsupported and unset values do not make readings.
+	assert.Nil(t, ctx.MakeReading(leaf, &opencfg.KeyValue{Value: &opencfg.KeyValue_BytesValue{BytesValue: []byte{1}}}))
+	assert.Nil(t, ctx.MakeReading(leaf, &opencfg.KeyValue{}))
+}
+
+func Test_parseOpenConfigPath(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected openConfigPath
+	}{
+		{
+			path:     "/system/state/hostname",
+			expected: openConfigPath{{Name: "system"}, {Name: "state"}, {Name: "hostname"}},
+		},
+		{
+			path: "/interfaces/interface[name='et-0/0/0']/state",
+			expected: openConfigPath{
+				{Name: "interfaces"},
+				{Name: "interface", Keys: []openConfigKey{{Name: "name", Value: "et-0/0/0"}}},
+				{Name: "state"},
+			},
+		},
+		{
+			path: `/components/component[name="FPC0"][type=LINECARD]/`,
+			expected: openConfigPath{
+				{Name: "components"},
+				{Name: "component", Keys: []openConfigKey{{Name: "name", Value: "FPC0"}, {Name: "type", Value: "LINECARD"}}},
+			},
+		},
+	}
+	for _, c := range cases {
+		actual, err := parseOpenConfigPath(c.path)
+		assert.NoError(t, err, c.path)
+		assert.Equal(t, c.expected, actual, c.path)
+	}
+}
+
+func Test_parseOpenConfigPath_Errors(t *testing.T) {
+	for _, path := range []string{
+		"/[name=a]",
+		"/interface[name=a",
+		"/interface[name]",
+	} {
+		_, err := parseOpenConfigPath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func Test_splitOpenConfigPath(t *testing.T) {
+	path, _ := parseOpenConfigPath("/interfaces/interface[name=et-0/0/0]/state/counters/in-octets")
+	device, leaf := splitOpenConfigPath(path)
+	assert.Equal(t, "/interfaces/interface[name=et-0/0/0]", device.String())
+	assert.Equal(t, "/state/counters/in-octets", leaf.String())
+
+	path, _ = parseOpenConfigPath("/system/state/hostname")
+	device, leaf = splitOpenConfigPath(path)
+	assert.Equal(t, "/system/state", device.String())
+	assert.Equal(t, "/hostname", leaf.String())
+}
+
+func Test_joinOpenConfigPath(t *testing.T) {
+	assert.Equal(t, "/a/b/c", joinOpenConfigPath("/a/b/", "c"))
+	assert.Equal(t, "/a/b/c", joinOpenConfigPath("/a/b", "c"))
+	assert.Equal(t, "/x/y", joinOpenConfigPath("/a/b", "/x/y"))
+	assert.Equal(t, "c", joinOpenConfigPath("", "c"))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/outputs"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/optics"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
@@ -18,6 +19,31 @@ type OpticsContext struct {
 	SystemID       string
 	ComponentID    uint32
 	SubComponentID uint32
+
+	// Hysteresis defines the margins applied when evaluating optics values against
+	// their thresholds.
+	Hysteresis config.HysteresisConfig
+
+	// Types maps optics type codes to transceiver types, taking precedence over
+	// the built-in mapping.
+	Types map[uint32]config.OpticsType
+
+	// thresholds tracks the severity of evaluated optics values across samples so
+	// hysteresis can be applied. If nil, each sample is evaluated independently.
+	thresholds *thresholdTracker
+
+	// interfaces is used to look up the interface device which an optic belongs
+	// to. If nil, optic devices are not linked to their interface devices.
+	interfaces *interfaceIndex
+
+	// filters define which interfaces and metrics are dropped before their readings
+	// are built and the thresholds are evaluated. If nil, nothing is filtered.
+	filters *config.FilterConfig
+
+	// redundancy checks whether each interface's data is from the active source of
+	// the stream, before its readings are built and the thresholds are evaluated. If nil, the
+	// data of all interfaces is used.
+	redundancy *streamSamples
 }
 
 // NewOpticsContextFromStream creates a new OpticsContext populated with values from
@@ -42,6 +68,13 @@ func (ctx *OpticsContext) Decode(opt *optics.Optics) ([]*IntermediaryDataContain
 	}
 
 	for _, info := range opt.GetOpticsDiag() {
+		if !allowsInterface(ctx.filters, info.GetIfName()) {
+			continue
+		}
+		if !ctx.redundancy.Allow(info.GetIfName()) {
+			continue
+		}
+
 		deviceInfo, err := ctx.MakeDeviceInfo(info)
 		if err != nil {
 			return nil, err
@@ -51,10 +84,58 @@ func (ctx *OpticsContext) Decode(opt *optics.Optics) ([]*IntermediaryDataContain
 			return nil, err
 		}
 
+		// Link the optic to the interface it belongs to, if that interface is known.
+		iface := ctx.interfaces.Lookup(ctx.SystemID, info.GetSnmpIfIndex(), info.GetIfName())
+		if iface != nil {
+			deviceInfo.Links = map[string]*DeviceInfo{
+				"interface_device_id": iface,
+			}
+		}
+
+		// Multi-lane optics (e.g. QSFP28) get a sub-device for each lane, which holds
+		// the readings for that lane. Single-lane optics keep all readings on the optic.
+		lanes := info.GetOpticsDiagStats().GetOpticsLaneDiagStats()
+		if len(lanes) <= 1 {
+			decoded = append(decoded, &IntermediaryDataContainer{
+				DeviceInfo: deviceInfo,
+				Readings:   readings,
+			})
+			continue
+		}
+
+		var opticReadings []*output.Reading
+		laneReadings := map[string][]*output.Reading{}
+		for _, r := range readings {
+			if lane, ok := r.Context["lane_number"]; ok {
+				laneReadings[lane] = append(laneReadings[lane], r)
+			} else {
+				opticReadings = append(opticReadings, r)
+			}
+		}
+
 		decoded = append(decoded, &IntermediaryDataContainer{
 			DeviceInfo: deviceInfo,
-			Readings:   readings,
+			Readings:   opticReadings,
 		})
+
+		for _, lane := range lanes {
+			laneNumber := fmt.Sprint(lane.GetLaneNumber())
+			laneInfo, err := ctx.MakeLaneDeviceInfo(info, lane.GetLaneNumber())
+			if err != nil {
+				return nil, err
+			}
+			laneInfo.Links = map[string]*DeviceInfo{
+				"optic_device_id": deviceInfo,
+			}
+			if iface != nil {
+				laneInfo.Links["interface_device_id"] = iface
+			}
+
+			decoded = append(decoded, &IntermediaryDataContainer{
+				DeviceInfo: laneInfo,
+				Readings:   laneReadings[laneNumber],
+			})
+		}
 	}
 	return decoded, nil
 }
@@ -75,187 +156,226 @@ func (ctx *OpticsContext) MakeDeviceInfo(info *optics.OpticsInfos) (*DeviceInfo,
 		return nil, errors.New("unable to load device info from optics context: info has no name")
 	}
 
-	// FIXME (etd): is this an interface? is this an "optic"? what do we call this device?
-	return &DeviceInfo{
-		Type: "interface",
-		Info: fmt.Sprintf("%s interface %s", ctx.SystemID, ifaceName),
+	deviceInfo := &DeviceInfo{
+		Type: "optic",
+		Info: fmt.Sprintf("%s optic %s", ctx.SystemID, ifaceName),
 		Tags: []string{
-			"vapor/networking:interface",
+			"vapor/networking:optic",
 		},
-		Context: map[string]string{
+		Context: addInterfaceContext(map[string]string{
 			"interface_name": ifaceName,
 			"system_id":      ctx.SystemID,
 			"metric_type":    "network",
+		}, ifaceName),
+		IDComponents: map[string]string{
+			"sys":  ctx.SystemID,
+			"if":   ifaceName,
+			"cid":  fmt.Sprint(ctx.ComponentID),
+			"scid": fmt.Sprint(ctx.SubComponentID),
 		},
+	}
+
+	// Describe the transceiver type, if the optic reports it.
+	if stats := info.GetOpticsDiagStats(); stats != nil && stats.OpticsType != nil {
+		for k, v := range opticsTypeContext(stats.GetOpticsType(), ctx.Types) {
+			deviceInfo.Context[k] = v
+		}
+		deviceInfo.Tags = append(deviceInfo.Tags, opticsTypeTags(stats.GetOpticsType(), ctx.Types)...)
+	}
+	return deviceInfo, nil
+}
+
+// MakeLaneDeviceInfo creates a DeviceInfo for a single lane of a multi-lane optic. The
+// DeviceInfo is used to generate SDK devices.
+func (ctx *OpticsContext) MakeLaneDeviceInfo(info *optics.OpticsInfos, lane uint32) (*DeviceInfo, error) {
+	if info == nil {
+		return nil, errors.New("unable to load lane device info from optics context: nil info")
+	}
+
+	if ctx.SystemID == "" {
+		return nil, errors.New("unable to load lane device info from optics context: context has no system ID")
+	}
+
+	ifaceName := info.GetIfName()
+	if ifaceName == "" {
+		return nil, errors.New("unable to load lane device info from optics context: info has no name")
+	}
+
+	laneNumber := fmt.Sprint(lane)
+	return &DeviceInfo{
+		Type: "optic-lane",
+		Info: fmt.Sprintf("%s optic %s lane %s", ctx.SystemID, ifaceName, laneNumber),
+		Tags: []string{
+			"vapor/networking:optic-lane",
+		},
+		Context: addInterfaceContext(map[string]string{
+			"interface_name": ifaceName,
+			"lane_number":    laneNumber,
+			"system_id":      ctx.SystemID,
+			"metric_type":    "network",
+		}, ifaceName),
 		IDComponents: map[string]string{
 			"sys":  ctx.SystemID,
 			"if":   ifaceName,
+			"lane": laneNumber,
 			"cid":  fmt.Sprint(ctx.ComponentID),
 			"scid": fmt.Sprint(ctx.SubComponentID),
 		},
 	}, nil
 }
 
+const (
+	// opticsReadings is the capacity reserved for the readings of an optic, excluding
+	// the readings for its lanes.
+	opticsReadings = 25
+
+	// laneReadings is the capacity reserved for the readings of each lane of an optic,
+	// including its status readings.
+	laneReadings = 22
+)
+
 // MakeReadings creates device readings for an OpticsInfos message. The message contains many data
 // points, all of which are translated into Synse readings.
 func (ctx *OpticsContext) MakeReadings(info *optics.OpticsInfos) ([]*output.Reading, error) {
 	stats := info.GetOpticsDiagStats()
 
-	var readings = []*output.Reading{
-		output.Number.MakeReading(stats.GetOpticsType()).WithContext(map[string]string{
-			"metric": "optics_type",
-		}),
-		output.Temperature.MakeReading(stats.GetModuleTemp()).WithContext(map[string]string{
-			"metric": "module_temp",
-		}),
-		output.Temperature.MakeReading(stats.GetModuleTempHighAlarmThreshold()).WithContext(map[string]string{
-			"metric": "module_temp_high_alarm_threshold",
-		}),
-		output.Temperature.MakeReading(stats.GetModuleTempLowAlarmThreshold()).WithContext(map[string]string{
-			"metric": "module_temp_low_alarm_threshold",
-		}),
-		output.Temperature.MakeReading(stats.GetModuleTempHighWarningThreshold()).WithContext(map[string]string{
-			"metric": "module_temp_high_warning_threshold",
-		}),
-		output.Temperature.MakeReading(stats.GetModuleTempLowWarningThreshold()).WithContext(map[string]string{
-			"metric": "module_temp_low_warning_threshold",
-		}),
-		outputs.DecibelMilliwatts.MakeReading(stats.GetLaserOutputPowerHighAlarmThresholdDbm()).WithContext(map[string]string{
-			"metric": "laser_output_power_high_alarm_threshold_dbm",
-		}),
-		outputs.DecibelMilliwatts.MakeReading(stats.GetLaserOutputPowerLowAlarmThresholdDbm()).WithContext(map[string]string{
-			"metric": "laser_output_power_low_alarm_threshold_dbm",
-		}),
-		outputs.DecibelMilliwatts.MakeReading(stats.GetLaserOutputPowerHighWarningThresholdDbm()).WithContext(map[string]string{
-			"metric": "laser_output_power_high_warning_threshold_dbm",
-		}),
-		outputs.DecibelMilliwatts.MakeReading(stats.GetLaserOutputPowerLowWarningThresholdDbm()).WithContext(map[string]string{
-			"metric": "laser_output_power_low_warning_threshold_dbm",
-		}),
-		outputs.DecibelMilliwatts.MakeReading(stats.GetLaserRxPowerHighAlarmThresholdDbm()).WithContext(map[string]string{
-			"metric": "laser_rx_power_high_alarm_threshold_dbm",
-		}),
-		outputs.DecibelMilliwatts.MakeReading(stats.GetLaserRxPowerLowAlarmThresholdDbm()).WithContext(map[string]string{
-			"metric": "laser_rx_power_low_alarm_threshold_dbm",
-		}),
-		outputs.DecibelMilliwatts.MakeReading(stats.GetLaserRxPowerHighWarningThresholdDbm()).WithContext(map[string]string{
-			"metric": "laser_rx_power_high_warning_threshold_dbm",
-		}),
-		outputs.DecibelMilliwatts.MakeReading(stats.GetLaserRxPowerLowWarningThresholdDbm()).WithContext(map[string]string{
-			"metric": "laser_rx_power_low_warning_threshold_dbm",
-		}),
-		outputs.Milliamperes.MakeReading(stats.GetLaserBiasCurrentHighAlarmThreshold()).WithContext(map[string]string{
-			"metric": "laser_bias_current_high_alarm_threshold",
-		}),
-		outputs.Milliamperes.MakeReading(stats.GetLaserBiasCurrentLowAlarmThreshold()).WithContext(map[string]string{
-			"metric": "laser_bias_current_low_alarm_threshold",
-		}),
-		outputs.Milliamperes.MakeReading(stats.GetLaserBiasCurrentHighWarningThreshold()).WithContext(map[string]string{
-			"metric": "laser_bias_current_high_warning_threshold",
-		}),
-		outputs.Milliamperes.MakeReading(stats.GetLaserBiasCurrentLowWarningThreshold()).WithContext(map[string]string{
-			"metric": "laser_bias_current_low_warning_threshold",
-		}),
-		outputs.Boolean.MakeReading(stats.GetModuleTempHighAlarm()).WithContext(map[string]string{
-			"metric": "module_temp_high_alarm",
-		}),
-		outputs.Boolean.MakeReading(stats.GetModuleTempLowAlarm()).WithContext(map[string]string{
-			"metric": "module_temp_low_alarm",
-		}),
-		outputs.Boolean.MakeReading(stats.GetModuleTempHighWarning()).WithContext(map[string]string{
-			"metric": "module_temp_high_warning",
-		}),
-		outputs.Boolean.MakeReading(stats.GetModuleTempLowWarning()).WithContext(map[string]string{
-			"metric": "module_temp_low_warning",
-		}),
+	lanes := stats.GetOpticsLaneDiagStats()
+	set := newReadingSet(opticsReadings+laneReadings*len(lanes), metricFilter(ctx.filters))
+
+	set.add(&output.Number, stats.GetOpticsType(), metricContext("optics_type"))
+	set.add(&output.Temperature, stats.GetModuleTemp(), metricContext("module_temp"))
+	set.add(&output.Temperature, stats.GetModuleTempHighAlarmThreshold(), metricContext("module_temp_high_alarm_threshold"))
+	set.add(&output.Temperature, stats.GetModuleTempLowAlarmThreshold(), metricContext("module_temp_low_alarm_threshold"))
+	set.add(&output.Temperature, stats.GetModuleTempHighWarningThreshold(), metricContext("module_temp_high_warning_threshold"))
+	set.add(&output.Temperature, stats.GetModuleTempLowWarningThreshold(), metricContext("module_temp_low_warning_threshold"))
+	set.add(&outputs.DecibelMilliwatts, stats.GetLaserOutputPowerHighAlarmThresholdDbm(), metricContext("laser_output_power_high_alarm_threshold_dbm"))
+	set.add(&outputs.DecibelMilliwatts, stats.GetLaserOutputPowerLowAlarmThresholdDbm(), metricContext("laser_output_power_low_alarm_threshold_dbm"))
+	set.add(&outputs.DecibelMilliwatts, stats.GetLaserOutputPowerHighWarningThresholdDbm(), metricContext("laser_output_power_high_warning_threshold_dbm"))
+	set.add(&outputs.DecibelMilliwatts, stats.GetLaserOutputPowerLowWarningThresholdDbm(), metricContext("laser_output_power_low_warning_threshold_dbm"))
+	set.add(&outputs.DecibelMilliwatts, stats.GetLaserRxPowerHighAlarmThresholdDbm(), metricContext("laser_rx_power_high_alarm_threshold_dbm"))
+	set.add(&outputs.DecibelMilliwatts, stats.GetLaserRxPowerLowAlarmThresholdDbm(), metricContext("laser_rx_power_low_alarm_threshold_dbm"))
+	set.add(&outputs.DecibelMilliwatts, stats.GetLaserRxPowerHighWarningThresholdDbm(), metricContext("laser_rx_power_high_warning_threshold_dbm"))
+	set.add(&outputs.DecibelMilliwatts, stats.GetLaserRxPowerLowWarningThresholdDbm(), metricContext("laser_rx_power_low_warning_threshold_dbm"))
+	set.add(&outputs.Milliamperes, stats.GetLaserBiasCurrentHighAlarmThreshold(), metricContext("laser_bias_current_high_alarm_threshold"))
+	set.add(&outputs.Milliamperes, stats.GetLaserBiasCurrentLowAlarmThreshold(), metricContext("laser_bias_current_low_alarm_threshold"))
+	set.add(&outputs.Milliamperes, stats.GetLaserBiasCurrentHighWarningThreshold(), metricContext("laser_bias_current_high_warning_threshold"))
+	set.add(&outputs.Milliamperes, stats.GetLaserBiasCurrentLowWarningThreshold(), metricContext("laser_bias_current_low_warning_threshold"))
+	set.add(&outputs.Boolean, stats.GetModuleTempHighAlarm(), metricContext("module_temp_high_alarm"))
+	set.add(&outputs.Boolean, stats.GetModuleTempLowAlarm(), metricContext("module_temp_low_alarm"))
+	set.add(&outputs.Boolean, stats.GetModuleTempHighWarning(), metricContext("module_temp_high_warning"))
+	set.add(&outputs.Boolean, stats.GetModuleTempLowWarning(), metricContext("module_temp_low_warning"))
+
+	if stats != nil && stats.OpticsType != nil {
+		set.add(&output.String, opticsTypeName(stats.GetOpticsType(), ctx.Types), metricContext("optics_type_name"))
 	}
 
-	var laneStats []*output.Reading
-	for _, stat := range info.OpticsDiagStats.GetOpticsLaneDiagStats() {
-		laneNumber := fmt.Sprint(stat.GetLaneNumber())
-
-		var q = []*output.Reading{
-			output.Temperature.MakeReading(stat.GetLaneLaserTemperature()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_temperature",
-			}),
-			outputs.DecibelMilliwatts.MakeReading(stat.GetLaneLaserOutputPowerDbm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_output_power_dbm",
-			}),
-			outputs.DecibelMilliwatts.MakeReading(stat.GetLaneLaserReceiverPowerDbm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_receiver_power_dbm",
-			}),
-			outputs.Milliamperes.MakeReading(stat.GetLaneLaserBiasCurrent()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_bias_current",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserOutputPowerHighAlarm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_output_power_high_alarm",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserOutputPowerLowAlarm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_output_power_low_alarm",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserOutputPowerHighWarning()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_output_power_high_warning",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserOutputPowerLowWarning()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_output_power_low_warning",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserReceiverPowerHighAlarm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_receiver_power_high_alarm",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserReceiverPowerLowAlarm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_receiver_power_low_alarm",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserReceiverPowerHighWarning()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_receiver_power_high_warning",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserReceiverPowerLowWarning()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_receiver_power_low_warning",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserBiasCurrentHighAlarm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_bias_current_high_alarm",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserBiasCurrentLowAlarm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_bias_current_low_alarm",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserBiasCurrentHighWarning()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_bias_current_high_warning",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneLaserBiasCurrentLowWarning()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_laser_bias_current_low_warning",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneTxLossOfSignalAlarm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_tx_loss_of_signal_alarm",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneRxLossOfSignalAlarm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_rx_loss_of_signal_alarm",
-			}),
-			outputs.Boolean.MakeReading(stat.GetLaneTxLaserDisabledAlarm()).WithContext(map[string]string{
-				"lane_number": laneNumber,
-				"metric":      "lane_tx_laser_disabled_alarm",
-			}),
-		}
-		laneStats = append(laneStats, q...)
+	for _, stat := range lanes {
+		lane := stat.GetLaneNumber()
+
+		set.add(&output.Temperature, stat.GetLaneLaserTemperature(), laneContext(lane, "lane_laser_temperature"))
+		set.add(&outputs.DecibelMilliwatts, stat.GetLaneLaserOutputPowerDbm(), laneContext(lane, "lane_laser_output_power_dbm"))
+		set.add(&outputs.DecibelMilliwatts, stat.GetLaneLaserReceiverPowerDbm(), laneContext(lane, "lane_laser_receiver_power_dbm"))
+		set.add(&outputs.Milliamperes, stat.GetLaneLaserBiasCurrent(), laneContext(lane, "lane_laser_bias_current"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserOutputPowerHighAlarm(), laneContext(lane, "lane_laser_output_power_high_alarm"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserOutputPowerLowAlarm(), laneContext(lane, "lane_laser_output_power_low_alarm"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserOutputPowerHighWarning(), laneContext(lane, "lane_laser_output_power_high_warning"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserOutputPowerLowWarning(), laneContext(lane, "lane_laser_output_power_low_warning"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserReceiverPowerHighAlarm(), laneContext(lane, "lane_laser_receiver_power_high_alarm"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserReceiverPowerLowAlarm(), laneContext(lane, "lane_laser_receiver_power_low_alarm"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserReceiverPowerHighWarning(), laneContext(lane, "lane_laser_receiver_power_high_warning"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserReceiverPowerLowWarning(), laneContext(lane, "lane_laser_receiver_power_low_warning"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserBiasCurrentHighAlarm(), laneContext(lane, "lane_laser_bias_current_high_alarm"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserBiasCurrentLowAlarm(), laneContext(lane, "lane_laser_bias_current_low_alarm"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserBiasCurrentHighWarning(), laneContext(lane, "lane_laser_bias_current_high_warning"))
+		set.add(&outputs.Boolean, stat.GetLaneLaserBiasCurrentLowWarning(), laneContext(lane, "lane_laser_bias_current_low_warning"))
+		set.add(&outputs.Boolean, stat.GetLaneTxLossOfSignalAlarm(), laneContext(lane, "lane_tx_loss_of_signal_alarm"))
+		set.add(&outputs.Boolean, stat.GetLaneRxLossOfSignalAlarm(), laneContext(lane, "lane_rx_loss_of_signal_alarm"))
+		set.add(&outputs.Boolean, stat.GetLaneTxLaserDisabledAlarm(), laneContext(lane, "lane_tx_laser_disabled_alarm"))
+	}
+
+	ctx.makeStatusReadings(set, info)
+	return set.Readings(), nil
+}
+
+// makeStatusReadings evaluates the module and per-lane values of an OpticsInfos message
+// against the thresholds reported by the optic, adding a status reading for each of the
+// evaluated values as well as an overall status reading for the optic to its set of
+// readings.
+//
+// The overall status is the most severe of the evaluated statuses and any alarms or
+// warnings which the optic itself reports.
+func (ctx *OpticsContext) makeStatusReadings(set *readingSet, info *optics.OpticsInfos) {
+	var severities []Severity
+
+	stats := info.GetOpticsDiagStats()
+	if stats == nil {
+		stats = &optics.OpticsDiagStats{}
+	}
+
+	evaluate := func(lane string, readingCtx map[string]string, value float64, t Thresholds, hysteresis float64) {
+		metric := readingCtx["metric"]
+		key := fmt.Sprintf("%s/%s/%s/%s", ctx.SystemID, info.GetIfName(), lane, metric)
+		severity := ctx.thresholds.Evaluate(key, value, t, hysteresis)
+		severities = append(severities, severity)
+		set.add(&output.Status, severity.String(), readingCtx)
+	}
+
+	if stats.ModuleTemp != nil {
+		evaluate("", metricContext("module_temp_status"), stats.GetModuleTemp(), Thresholds{
+			HighAlarm:   stats.ModuleTempHighAlarmThreshold,
+			LowAlarm:    stats.ModuleTempLowAlarmThreshold,
+			HighWarning: stats.ModuleTempHighWarningThreshold,
+			LowWarning:  stats.ModuleTempLowWarningThreshold,
+		}, ctx.Hysteresis.Temperature)
+	}
+	if stats.GetModuleTempHighAlarm() || stats.GetModuleTempLowAlarm() {
+		severities = append(severities, SeverityAlarm)
 	}
+	if stats.GetModuleTempHighWarning() || stats.GetModuleTempLowWarning() {
+		severities = append(severities, SeverityWarning)
+	}
+
+	for _, stat := range stats.GetOpticsLaneDiagStats() {
+		lane := stat.GetLaneNumber()
+		laneNumber := formatNumber(lane)
 
-	readings = append(readings, laneStats...)
+		if stat.LaneLaserOutputPowerDbm != nil {
+			evaluate(laneNumber, laneContext(lane, "lane_laser_output_power_status"), stat.GetLaneLaserOutputPowerDbm(), Thresholds{
+				HighAlarm:   stats.LaserOutputPowerHighAlarmThresholdDbm,
+				LowAlarm:    stats.LaserOutputPowerLowAlarmThresholdDbm,
+				HighWarning: stats.LaserOutputPowerHighWarningThresholdDbm,
+				LowWarning:  stats.LaserOutputPowerLowWarningThresholdDbm,
+			}, ctx.Hysteresis.Power)
+		}
+		if stat.LaneLaserReceiverPowerDbm != nil {
+			evaluate(laneNumber, laneContext(lane, "lane_laser_receiver_power_status"), stat.GetLaneLaserReceiverPowerDbm(), Thresholds{
+				HighAlarm:   stats.LaserRxPowerHighAlarmThresholdDbm,
+				LowAlarm:    stats.LaserRxPowerLowAlarmThresholdDbm,
+				HighWarning: stats.LaserRxPowerHighWarningThresholdDbm,
+				LowWarning:  stats.LaserRxPowerLowWarningThresholdDbm,
+			}, ctx.Hysteresis.Power)
+		}
+		if stat.LaneLaserBiasCurrent != nil {
+			evaluate(laneNumber, laneContext(lane, "lane_laser_bias_current_status"), stat.GetLaneLaserBiasCurrent(), Thresholds{
+				HighAlarm:   stats.LaserBiasCurrentHighAlarmThreshold,
+				LowAlarm:    stats.LaserBiasCurrentLowAlarmThreshold,
+				HighWarning: stats.LaserBiasCurrentHighWarningThreshold,
+				LowWarning:  stats.LaserBiasCurrentLowWarningThreshold,
+			}, ctx.Hysteresis.Current)
+		}
+
+		if stat.GetLaneLaserOutputPowerHighAlarm() || stat.GetLaneLaserOutputPowerLowAlarm() ||
+			stat.GetLaneLaserReceiverPowerHighAlarm() || stat.GetLaneLaserReceiverPowerLowAlarm() ||
+			stat.GetLaneLaserBiasCurrentHighAlarm() || stat.GetLaneLaserBiasCurrentLowAlarm() ||
+			stat.GetLaneTxLossOfSignalAlarm() || stat.GetLaneRxLossOfSignalAlarm() {
+			severities = append(severities, SeverityAlarm)
+		}
+		if stat.GetLaneLaserOutputPowerHighWarning() || stat.GetLaneLaserOutputPowerLowWarning() ||
+			stat.GetLaneLaserReceiverPowerHighWarning() || stat.GetLaneLaserReceiverPowerLowWarning() ||
+			stat.GetLaneLaserBiasCurrentHighWarning() || stat.GetLaneLaserBiasCurrentLowWarning() {
+			severities = append(severities, SeverityWarning)
+		}
+	}
 
-	return readings, nil
+	set.add(&output.Status, maxSeverity(severities...).String(), metricContext("optics_status"))
 }
@@ -1,9 +1,11 @@
 package jti
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/optics"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
 )
@@ -97,13 +99,14 @@ func TestOpticsContext_MakeDeviceInfo(t *testing.T) {
 	info, err := ctx.MakeDeviceInfo(infos)
 	assert.NoError(t, err)
 	assert.NotNil(t, info)
-	assert.Equal(t, "interface", info.Type)
-	assert.Equal(t, "test interface string", info.Info)
-	assert.Equal(t, []string{"vapor/networking:interface"}, info.Tags)
+	assert.Equal(t, "optic", info.Type)
+	assert.Equal(t, "test optic string", info.Info)
+	assert.Equal(t, []string{"vapor/networking:optic", "jti/form_factor:GBIC"}, info.Tags)
 	assert.Equal(t, map[string]string{
 		"interface_name": "string",
 		"system_id":      "test",
 		"metric_type":    "network",
+		"form_factor":    "GBIC",
 	}, info.Context)
 	assert.Equal(t, map[string]string{
 		"sys":  "test",
@@ -182,7 +185,7 @@ func TestOpticsContext_MakeReadings(t *testing.T) {
 
 	readings, err := ctx.MakeReadings(infos)
 	assert.NoError(t, err)
-	assert.Len(t, readings, 22)
+	assert.Len(t, readings, 25)
 }
 
 func TestOpticsContext_MakeReadings2(t *testing.T) {
@@ -205,5 +208,297 @@ func TestOpticsContext_MakeReadings2(t *testing.T) {
 
 	readings, err := ctx.MakeReadings(infos)
 	assert.NoError(t, err)
-	assert.Len(t, readings, 41)
+	assert.Len(t, readings, 44)
+}
+
+func TestOpticsContext_MakeReadings_Status(t *testing.T) {
+	ctx := OpticsContext{
+		SensorName:     "sensor",
+		SystemID:       "test",
+		ComponentID:    2,
+		SubComponentID: 0,
+		Hysteresis: config.HysteresisConfig{
+			Temperature: 1,
+			Power:       0.5,
+			Current:     0.5,
+		},
+		thresholds: newThresholdTracker(),
+	}
+	lane := uint32(0)
+	infos := &optics.OpticsInfos{
+		IfName: &stringVal,
+		OpticsDiagStats: &optics.OpticsDiagStats{
+			ModuleTemp:                            float64Ptr(72),
+			ModuleTempHighAlarmThreshold:          float64Ptr(80),
+			ModuleTempHighWarningThreshold:        float64Ptr(70),
+			LaserRxPowerLowAlarmThresholdDbm:      float64Ptr(-14),
+			LaserRxPowerLowWarningThresholdDbm:    float64Ptr(-10),
+			LaserOutputPowerHighAlarmThresholdDbm: float64Ptr(5),
+			OpticsLaneDiagStats: []*optics.OpticsDiagLaneStats{{
+				LaneNumber:                &lane,
+				LaneLaserOutputPowerDbm:   float64Ptr(1),
+				LaneLaserReceiverPowerDbm: float64Ptr(-15),
+			}},
+		},
+	}
+
+	readings, err := ctx.MakeReadings(infos)
+	assert.NoError(t, err)
+
+	statuses := map[string]interface{}{}
+	for _, r := range readings {
+		if r.Type == "status" {
+			statuses[r.Context["metric"]] = r.Value
+		}
+	}
+	assert.Equal(t, map[string]interface{}{
+		"module_temp_status":               "warning",
+		"lane_laser_output_power_status":   "ok",
+		"lane_laser_receiver_power_status": "alarm",
+		"optics_status":                    "alarm",
+	}, statuses)
+
+	// Within the hysteresis margin, the status is held.
+	infos.OpticsDiagStats.OpticsLaneDiagStats[0].LaneLaserReceiverPowerDbm = float64Ptr(-13.8)
+	readings, err = ctx.MakeReadings(infos)
+	assert.NoError(t, err)
+	for _, r := range readings {
+		if r.Context["metric"] == "lane_laser_receiver_power_status" {
+			assert.Equal(t, "alarm", r.Value)
+		}
+	}
+}
+
+func TestOpticsContext_MakeReadings_StatusReportedAlarm(t *testing.T) {
+	ctx := OpticsContext{
+		SensorName: "sensor",
+		SystemID:   "test",
+	}
+	lane := uint32(0)
+	alarm := true
+	infos := &optics.OpticsInfos{
+		IfName: &stringVal,
+		OpticsDiagStats: &optics.OpticsDiagStats{
+			OpticsLaneDiagStats: []*optics.OpticsDiagLaneStats{{
+				LaneNumber:              &lane,
+				LaneRxLossOfSignalAlarm: &alarm,
+			}},
+		},
+	}
+
+	readings, err := ctx.MakeReadings(infos)
+	assert.NoError(t, err)
+
+	last := readings[len(readings)-1]
+	assert.Equal(t, "optics_status", last.Context["metric"])
+	assert.Equal(t, "alarm", last.Value)
+}
+
+func TestOpticsContext_MakeReadings_NoStats(t *testing.T) {
+	ctx := OpticsContext{
+		SensorName: "sensor",
+		SystemID:   "test",
+	}
+
+	readings, err := ctx.MakeReadings(&optics.OpticsInfos{IfName: &stringVal})
+	assert.NoError(t, err)
+
+	last := readings[len(readings)-1]
+	assert.Equal(t, "optics_status", last.Context["metric"])
+	assert.Equal(t, "ok", last.Value)
+}
+
+func TestOpticsContext_MakeDeviceInfo_InterfaceLocation(t *testing.T) {
+	ctx := OpticsContext{
+		SensorName: "sensor",
+		SystemID:   "test",
+	}
+	name := "xe-0/0/1"
+
+	info, err := ctx.MakeDeviceInfo(&optics.OpticsInfos{IfName: &name})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"interface_name": "xe-0/0/1",
+		"system_id":      "test",
+		"metric_type":    "network",
+		"media":          "xe",
+		"fpc":            "0",
+		"pic":            "0",
+		"port":           "1",
+	}, info.Context)
+}
+
+func TestOpticsContext_MakeLaneDeviceInfo(t *testing.T) {
+	ctx := OpticsContext{
+		SensorName:     "sensor",
+		SystemID:       "test",
+		ComponentID:    2,
+		SubComponentID: 0,
+	}
+	name := "et-0/0/0"
+
+	info, err := ctx.MakeLaneDeviceInfo(&optics.OpticsInfos{IfName: &name}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "optic-lane", info.Type)
+	assert.Equal(t, "test optic et-0/0/0 lane 3", info.Info)
+	assert.Equal(t, []string{"vapor/networking:optic-lane"}, info.Tags)
+	assert.Equal(t, map[string]string{
+		"interface_name": "et-0/0/0",
+		"lane_number":    "3",
+		"system_id":      "test",
+		"metric_type":    "network",
+		"media":          "et",
+		"fpc":            "0",
+		"pic":            "0",
+		"port":           "0",
+	}, info.Context)
+	assert.Equal(t, map[string]string{
+		"sys":  "test",
+		"if":   "et-0/0/0",
+		"lane": "3",
+		"cid":  "2",
+		"scid": "0",
+	}, info.IDComponents)
+}
+
+func TestOpticsContext_MakeLaneDeviceInfo_Errors(t *testing.T) {
+	ctx := OpticsContext{SystemID: "test"}
+
+	info, err := ctx.MakeLaneDeviceInfo(nil, 0)
+	assert.Error(t, err)
+	assert.Nil(t, info)
+
+	info, err = ctx.MakeLaneDeviceInfo(&optics.OpticsInfos{}, 0)
+	assert.Error(t, err)
+	assert.Nil(t, info)
+
+	ctx = OpticsContext{}
+	info, err = ctx.MakeLaneDeviceInfo(&optics.OpticsInfos{IfName: &stringVal}, 0)
+	assert.Error(t, err)
+	assert.Nil(t, info)
+}
+
+func TestOpticsContext_Decode_Lanes(t *testing.T) {
+	index := newInterfaceIndex()
+	index.Record("test", 520, "et-0/0/0", &DeviceInfo{
+		Type:         "interface",
+		IDComponents: map[string]string{"sys": "test", "if": "et-0/0/0"},
+	})
+
+	ctx := OpticsContext{
+		SensorName: "sensor",
+		SystemID:   "test",
+		interfaces: index,
+	}
+	name := "et-0/0/0"
+	snmpIndex := uint32(520)
+	lane0, lane1 := uint32(0), uint32(1)
+
+	data, err := ctx.Decode(&optics.Optics{
+		OpticsDiag: []*optics.OpticsInfos{{
+			IfName:      &name,
+			SnmpIfIndex: &snmpIndex,
+			OpticsDiagStats: &optics.OpticsDiagStats{
+				ModuleTemp: &float64Val,
+				OpticsLaneDiagStats: []*optics.OpticsDiagLaneStats{
+					{LaneNumber: &lane0, LaneLaserTemperature: &float64Val},
+					{LaneNumber: &lane1, LaneLaserTemperature: &float64Val},
+				},
+			},
+		}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, data, 3)
+
+	optic := data[0]
+	assert.Equal(t, "optic", optic.DeviceInfo.Type)
+	assert.Equal(t, "interface", optic.DeviceInfo.Links["interface_device_id"].Type)
+	for _, r := range optic.Readings {
+		assert.NotContains(t, r.Context, "lane_number")
+	}
+
+	for i, lane := range data[1:] {
+		assert.Equal(t, "optic-lane", lane.DeviceInfo.Type)
+		assert.Equal(t, fmt.Sprint(i), lane.DeviceInfo.Context["lane_number"])
+		assert.Equal(t, optic.DeviceInfo, lane.DeviceInfo.Links["optic_device_id"])
+		assert.Equal(t, "interface", lane.DeviceInfo.Links["interface_device_id"].Type)
+		assert.NotEmpty(t, lane.Readings)
+		for _, r := range lane.Readings {
+			assert.Equal(t, fmt.Sprint(i), r.Context["lane_number"])
+		}
+	}
+}
+
+func TestOpticsContext_Decode_SingleLane(t *testing.T) {
+	ctx := OpticsContext{
+		SensorName: "sensor",
+		SystemID:   "test",
+		interfaces: newInterfaceIndex(),
+	}
+	lane := uint32(0)
+
+	data, err := ctx.Decode(&optics.Optics{
+		OpticsDiag: []*optics.OpticsInfos{{
+			IfName: &stringVal,
+			OpticsDiagStats: &optics.OpticsDiagStats{
+				OpticsLaneDiagStats: []*optics.OpticsDiagLaneStats{
+					{LaneNumber: &lane, LaneLaserTemperature: &float64Val},
+				},
+			},
+		}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+	assert.Equal(t, "optic", data[0].DeviceInfo.Type)
+
+	// The interface is not known, so the optic is not linked.
+	assert.Nil(t, data[0].DeviceInfo.Links)
+}
+
+func TestOpticsContext_MakeDeviceInfo_OpticsType(t *testing.T) {
+	ctx := OpticsContext{
+		SensorName: "sensor",
+		SystemID:   "test",
+		Types: map[uint32]config.OpticsType{
+			0x11: {Media: "LR4"},
+		},
+	}
+	code := uint32(0x11)
+
+	info, err := ctx.MakeDeviceInfo(&optics.OpticsInfos{
+		IfName:          &stringVal,
+		OpticsDiagStats: &optics.OpticsDiagStats{OpticsType: &code},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "QSFP28", info.Context["form_factor"])
+	assert.Equal(t, "LR4", info.Context["optics_media"])
+	assert.Equal(t, []string{
+		"vapor/networking:optic",
+		"jti/form_factor:QSFP28",
+		"jti/optics_media:LR4",
+	}, info.Tags)
+
+	readings, err := ctx.MakeReadings(&optics.OpticsInfos{
+		IfName:          &stringVal,
+		OpticsDiagStats: &optics.OpticsDiagStats{OpticsType: &code},
+	})
+	assert.NoError(t, err)
+	for _, r := range readings {
+		if r.Context["metric"] == "optics_type_name" {
+			assert.Equal(t, "QSFP28 LR4", r.Value)
+		}
+	}
+}
+
+func TestOpticsContext_MakeDeviceInfo_NoOpticsType(t *testing.T) {
+	ctx := OpticsContext{
+		SensorName: "sensor",
+		SystemID:   "test",
+	}
+
+	info, err := ctx.MakeDeviceInfo(&optics.OpticsInfos{IfName: &stringVal})
+	assert.NoError(t, err)
+	assert.NotContains(t, info.Context, "form_factor")
+	assert.NotContains(t, info.Context, "optics_type")
+	assert.Equal(t, []string{"vapor/networking:optic"}, info.Tags)
 }
@@ -0,0 +1,105 @@
+package jti
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+)
+
+// opticsTypes is the built-in mapping of optics type codes to the transceiver type
+// they describe.
+//
+// The codes are the module identifiers defined by SFF-8024 (Table 4-1, "Identifier
+// Values"), which the optics type is assumed to be, as this is the identifier which
+// the transceiver itself reports. The optics sensor does not document the field, and
+// this has not been verified against the data of every Junos platform; codes which
+// differ can be corrected via the plugin configuration.
+//
+// The identifier describes the form factor of the transceiver but not its media, and
+// no other field of the optics sensor carries the media, so the built-in mapping has
+// no media names. Media names must be configured for the codes in use.
+var opticsTypes = map[uint32]config.OpticsType{
+	0x01: {FormFactor: "GBIC"},
+	0x03: {FormFactor: "SFP"},
+	0x06: {FormFactor: "XFP"},
+	0x0B: {FormFactor: "DWDM-SFP"},
+	0x0C: {FormFactor: "QSFP"},
+	0x0D: {FormFactor: "QSFP+"},
+	0x0E: {FormFactor: "CXP"},
+	0x11: {FormFactor: "QSFP28"},
+	0x12: {FormFactor: "CXP2"},
+	0x18: {FormFactor: "QSFP-DD"},
+	0x19: {FormFactor: "OSFP"},
+	0x1A: {FormFactor: "SFP-DD"},
+	0x1B: {FormFactor: "DSFP"},
+}
+
+// lookupOpticsType gets the transceiver type for an optics type code. Fields set in
+// the overrides take precedence over the built-in mapping. If the code is not known,
+// false is returned.
+func lookupOpticsType(code uint32, overrides map[uint32]config.OpticsType) (config.OpticsType, bool) {
+	t, found := opticsTypes[code]
+	if override, ok := overrides[code]; ok {
+		found = true
+		if override.FormFactor != "" {
+			t.FormFactor = override.FormFactor
+		}
+		if override.Media != "" {
+			t.Media = override.Media
+		}
+	}
+	return t, found
+}
+
+// opticsTypeName gets the display name for an optics type code, e.g. "QSFP28 LR4". If
+// the code is not known, the raw code is used, e.g. "unknown (42)".
+func opticsTypeName(code uint32, overrides map[uint32]config.OpticsType) string {
+	t, ok := lookupOpticsType(code, overrides)
+	if !ok {
+		return fmt.Sprintf("unknown (%d)", code)
+	}
+	return strings.TrimSpace(t.FormFactor + " " + t.Media)
+}
+
+// opticsTypeContext gets the device context for an optics type code. Only the fields
+// of the transceiver type which are known are included. If the code is not known, the
+// raw code is included instead.
+func opticsTypeContext(code uint32, overrides map[uint32]config.OpticsType) map[string]string {
+	t, ok := lookupOpticsType(code, overrides)
+	if !ok {
+		return map[string]string{
+			"optics_type": fmt.Sprint(code),
+		}
+	}
+
+	ctx := map[string]string{}
+	if t.FormFactor != "" {
+		ctx["form_factor"] = t.FormFactor
+	}
+	if t.Media != "" {
+		ctx["optics_media"] = t.Media
+	}
+	return ctx
+}
+
+// opticsTypeTags gets the device tags for an optics type code, in the form
+// "jti/<field>:<value>". Values which are not valid tag labels are not tagged.
+func opticsTypeTags(code uint32, overrides map[uint32]config.OpticsType) []string {
+	t, ok := lookupOpticsType(code, overrides)
+	if !ok {
+		return nil
+	}
+
+	var tags []string
+	for _, f := range []struct{ key, value string }{
+		{"form_factor", t.FormFactor},
+		{"optics_media", t.Media},
+	} {
+		if f.value == "" || strings.ContainsAny(f.value, ":/ \t") {
+			continue
+		}
+		tags = append(tags, fmt.Sprintf("jti/%s:%s", f.key, f.value))
+	}
+	return tags
+}
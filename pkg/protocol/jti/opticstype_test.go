@@ -0,0 +1,102 @@
+package jti
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+)
+
+func Test_lookupOpticsType(t *testing.T) {
+	overrides := map[uint32]config.OpticsType{
+		0x11: {Media: "LR4"},
+		0x03: {FormFactor: "SFP+", Media: "SR"},
+		0x80: {FormFactor: "CFP2"},
+	}
+
+	cases := []struct {
+		code     uint32
+		expected config.OpticsType
+		found    bool
+	}{
+		{code: 0x0D, expected: config.OpticsType{FormFactor: "QSFP+"}, found: true},
+		{code: 0x11, expected: config.OpticsType{FormFactor: "QSFP28", Media: "LR4"}, found: true},
+		{code: 0x03, expected: config.OpticsType{FormFactor: "SFP+", Media: "SR"}, found: true},
+		{code: 0x80, expected: config.OpticsType{FormFactor: "CFP2"}, found: true},
+		{code: 0x81, expected: config.OpticsType{}, found: false},
+	}
+
+	for _, c := range cases {
+		actual, found := lookupOpticsType(c.code, overrides)
+		assert.Equal(t, c.found, found, c.code)
+		assert.Equal(t, c.expected, actual, c.code)
+	}
+}
+
+func Test_opticsTypeName(t *testing.T) {
+	overrides := map[uint32]config.OpticsType{
+		0x11: {Media: "LR4"},
+		0x80: {Media: "ZR"},
+	}
+
+	assert.Equal(t, "QSFP-DD", opticsTypeName(0x18, nil))
+	assert.Equal(t, "QSFP28 LR4", opticsTypeName(0x11, overrides))
+	assert.Equal(t, "ZR", opticsTypeName(0x80, overrides))
+	assert.Equal(t, "unknown (42)", opticsTypeName(42, overrides))
+}
+
+func Test_opticsTypeContext(t *testing.T) {
+	overrides := map[uint32]config.OpticsType{
+		0x11: {Media: "LR4"},
+	}
+
+	assert.Equal(t, map[string]string{"form_factor": "QSFP28", "optics_media": "LR4"}, opticsTypeContext(0x11, overrides))
+	assert.Equal(t, map[string]string{"form_factor": "OSFP"}, opticsTypeContext(0x19, overrides))
+	assert.Equal(t, map[string]string{"optics_type": "42"}, opticsTypeContext(42, overrides))
+}
+
+func Test_opticsTypeTags(t *testing.T) {
+	overrides := map[uint32]config.OpticsType{
+		0x11: {Media: "100GBASE LR4"},
+	}
+
+	// Values which are not valid tag labels are not tagged.
+	assert.Equal(t, []string{"jti/form_factor:QSFP28"}, opticsTypeTags(0x11, overrides))
+	assert.Nil(t, opticsTypeTags(42, overrides))
+}
+
+func Test_opticsTypes_SFF8024(t *testing.T) {
+	// The built-in form factors are those of the SFF-8024 identifier values
+	// (Table 4-1) for the same codes.
+	identifiers := map[uint32]string{
+		0x01: "GBIC",
+		0x03: "SFP",
+		0x06: "XFP",
+		0x0B: "DWDM-SFP",
+		0x0C: "QSFP",
+		0x0D: "QSFP+",
+		0x0E: "CXP",
+		0x11: "QSFP28",
+		0x12: "CXP2",
+		0x18: "QSFP-DD",
+		0x19: "OSFP",
+		0x1A: "SFP-DD",
+		0x1B: "DSFP",
+	}
+	assert.Len(t, opticsTypes, len(identifiers))
+	for code, formFactor := range identifiers {
+		assert.Equal(t, config.OpticsType{FormFactor: formFactor}, opticsTypes[code], code)
+	}
+}
+
+func Test_opticsType_KnownCodeWithMedia(t *testing.T) {
+	// A known code with configured media reports both its built-in form factor and
+	// the media, as the name, the context, and the tags.
+	overrides := map[uint32]config.OpticsType{
+		0x11: {Media: "LR4"},
+	}
+
+	assert.Equal(t, "QSFP28 LR4", opticsTypeName(0x11, overrides))
+	assert.Equal(t, map[string]string{"form_factor": "QSFP28", "optics_media": "LR4"}, opticsTypeContext(0x11, overrides))
+	assert.Equal(t, []string{"jti/form_factor:QSFP28", "jti/optics_media:LR4"}, opticsTypeTags(0x11, overrides))
+}
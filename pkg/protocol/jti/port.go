@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/prometheus/common/log"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/outputs"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
@@ -18,6 +19,27 @@ type PortContext struct {
 	SystemID       string
 	ComponentID    uint32
 	SubComponentID uint32
+
+	// bundles tracks Aggregated Ethernet bundle membership across samples. If
+	// nil, no bundle devices are generated.
+	bundles *bundleTracker
+
+	// flaps tracks interface transitions across samples to detect link flaps. If
+	// nil, no link flap readings are generated.
+	flaps *flapTracker
+
+	// interfaces records the interface devices so that devices from other sensors
+	// can be linked to them. If nil, interfaces are not recorded.
+	interfaces *interfaceIndex
+
+	// filters define which interfaces and metrics are dropped before their readings
+	// are built and the trackers are updated. If nil, nothing is filtered.
+	filters *config.FilterConfig
+
+	// redundancy checks whether each interface's data is from the active source of
+	// the stream, before its readings are built and the trackers are updated. If nil, the
+	// data of all interfaces is used.
+	redundancy *streamSamples
 }
 
 // NewPortContextFromStream creates a new PortContext populated with values from
@@ -41,7 +63,19 @@ func (ctx *PortContext) Decode(prt *port.Port) ([]*IntermediaryDataContainer, er
 		return decoded, nil
 	}
 
+	// Track the bundles whose members were updated, in the order they were first seen,
+	// so a bundle device is only generated once per message.
+	var bundles []string
+	seen := map[string]bool{}
+
 	for _, info := range prt.GetInterfaceStats() {
+		if !allowsInterface(ctx.filters, info.GetIfName()) {
+			continue
+		}
+		if !ctx.redundancy.Allow(info.GetIfName()) {
+			continue
+		}
+
 		deviceInfo, err := ctx.MakeDeviceInfo(info)
 		if err != nil {
 			return nil, err
@@ -55,6 +89,37 @@ func (ctx *PortContext) Decode(prt *port.Port) ([]*IntermediaryDataContainer, er
 			DeviceInfo: deviceInfo,
 			Readings:   readings,
 		})
+
+		if ctx.interfaces != nil {
+			ctx.interfaces.Record(ctx.SystemID, info.GetSnmpIfIndex(), info.GetIfName(), deviceInfo)
+		}
+
+		if ctx.bundles != nil {
+			if bundle := ctx.bundles.Update(ctx.SystemID, info); bundle != "" && !seen[bundle] {
+				seen[bundle] = true
+				bundles = append(bundles, bundle)
+			}
+		}
+	}
+
+	for _, bundle := range bundles {
+		if !allowsInterface(ctx.filters, bundle) {
+			continue
+		}
+
+		deviceInfo, err := ctx.MakeBundleDeviceInfo(bundle)
+		if err != nil {
+			return nil, err
+		}
+		readings, err := ctx.MakeBundleReadings(ctx.bundles.Members(ctx.SystemID, bundle))
+		if err != nil {
+			return nil, err
+		}
+
+		decoded = append(decoded, &IntermediaryDataContainer{
+			DeviceInfo: deviceInfo,
+			Readings:   readings,
+		})
 	}
 	return decoded, nil
 }
@@ -81,12 +146,12 @@ func (ctx *PortContext) MakeDeviceInfo(iface *port.InterfaceInfos) (*DeviceInfo,
 		Tags: []string{
 			"vapor/networking:interface",
 		},
-		Context: map[string]string{
+		Context: addInterfaceContext(map[string]string{
 			"interface_name": ifaceName,
 			"system_id":      ctx.SystemID,
 			"metric_type":    "network",
 			"parent_ae_name": iface.GetParentAeName(),
-		},
+		}, ifaceName),
 		IDComponents: map[string]string{
 			"sys":  ctx.SystemID,
 			"if":   ifaceName,
@@ -96,319 +161,145 @@ func (ctx *PortContext) MakeDeviceInfo(iface *port.InterfaceInfos) (*DeviceInfo,
 	}, nil
 }
 
+const (
+	// portReadings is the capacity reserved for the readings of an interface, excluding
+	// the readings for its queues. This covers the fixed readings, the utilization readings
+	// and the link flap readings for the default flap windows.
+	portReadings = 48
+
+	// queueReadings is the number of readings for each queue of an interface.
+	queueReadings = 11
+)
+
 // MakeReadings creates device readings for an InterfaceInfos message. The message contains many data
 // points, all of which are translated into Synse readings.
 func (ctx *PortContext) MakeReadings(iface *port.InterfaceInfos) ([]*output.Reading, error) {
-	// TODO (etd): maybe there is a more programmatic way of being able to construct these? e.g.
-	// 	 via reflection?
-
-	var readings = []*output.Reading{
-		// -*- Bytes Counter Outputs -*-
-		outputs.BytesCounter.MakeReading(iface.IngressStats.GetIfOctets()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_octets",
-		}),
-
-		outputs.BytesCounter.MakeReading(iface.EgressStats.GetIfOctets()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_octets",
-		}),
-
-		// -*- Bytes per Second Outputs -*-
-		outputs.BytesPerSecond.MakeReading(iface.IngressStats.GetIf_1SecOctets()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_1sec_octets",
-		}),
-
-		outputs.BytesPerSecond.MakeReading(iface.EgressStats.GetIf_1SecOctets()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_1sec_octets",
-		}),
-
-		// -*- Megabits per second Outputs -*-
-		outputs.MegabitPerSecond.MakeReading(iface.GetIfHighSpeed()).WithContext(map[string]string{
-			"metric": "if_high_speed",
-		}),
-
-		// -*- Number Outputs -*-
-		output.Number.MakeReading(iface.IngressErrors.GetIfInFifoErrors()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_in_fifo_errors",
-		}),
-		output.Number.MakeReading(iface.IngressErrors.GetIfInResourceErrors()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_in_resource_errors",
-		}),
-
-		// -*- Packets Counter Outputs -*-
-		outputs.PacketsCounter.MakeReading(iface.IngressStats.GetIfPkts()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressStats.GetIfUcPkts()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_uc_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressStats.GetIfMcPkts()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_mc_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressStats.GetIfBcPkts()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_bc_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressStats.GetIfError()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_error",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressStats.GetIfPausePkts()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_pause_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressStats.GetIfUnknownProtoPkts()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_unknown_proto_pkts",
-		}),
-
-		outputs.PacketsCounter.MakeReading(iface.EgressStats.GetIfPkts()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.EgressStats.GetIfUcPkts()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_uc_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.EgressStats.GetIfMcPkts()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_mc_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.EgressStats.GetIfBcPkts()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_bc_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.EgressStats.GetIfError()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_error",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.EgressStats.GetIfPausePkts()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_pause_pkts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.EgressStats.GetIfUnknownProtoPkts()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_unknown_proto_pkts",
-		}),
-
-		outputs.PacketsCounter.MakeReading(iface.EgressErrors.GetIfErrors()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_errors",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.EgressErrors.GetIfDiscards()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_discards",
-		}),
-
-		outputs.PacketsCounter.MakeReading(iface.IngressErrors.GetIfErrors()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_errors",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressErrors.GetIfInQdrops()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_in_qdrops",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressErrors.GetIfInFrameErrors()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_in_frame_errors",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressErrors.GetIfDiscards()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_discards",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressErrors.GetIfInRunts()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_in_runts",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressErrors.GetIfInL3Incompletes()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_in_l3_incompletes",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressErrors.GetIfInL2ChanErrors()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_in_l2chan_errors",
-		}),
-		outputs.PacketsCounter.MakeReading(iface.IngressErrors.GetIfInL2MismatchTimeouts()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_in_l2_mismatch_timeouts",
-		}),
-
-		// -*- Packets per Second Outputs -*-
-		outputs.PacketsPerSecond.MakeReading(iface.IngressStats.GetIf_1SecPkts()).WithContext(map[string]string{
-			"direction": "ingress",
-			"metric":    "if_1sec_pkts",
-		}),
-
-		outputs.PacketsPerSecond.MakeReading(iface.EgressStats.GetIf_1SecPkts()).WithContext(map[string]string{
-			"direction": "egress",
-			"metric":    "if_1sec_pkts",
-		}),
-
-		// -*- Timestamp Outputs -*-
-		output.Timestamp.MakeReading(iface.GetInitTime()).WithContext(map[string]string{
-			"metric": "init_time",
-		}),
-
-		// -*- Time Tick Outputs -*-
-		outputs.TimeTicks.MakeReading(iface.GetIfLastChange()).WithContext(map[string]string{
-			"metric": "if_last_change",
-		}),
-
-		// -*- Status Outputs -*-
-		output.Status.MakeReading(iface.GetIfAdministrationStatus()).WithContext(map[string]string{
-			"metric": "if_administration_status",
-		}),
-		output.Status.MakeReading(iface.GetIfOperationalStatus()).WithContext(map[string]string{
-			"metric": "if_operational_status",
-		}),
-
-		// -*- String Outputs -*-
-		output.String.MakeReading(iface.GetIfDescription()).WithContext(map[string]string{
-			"metric": "if_description",
-		}),
-		output.String.MakeReading(iface.GetParentAeName()).WithContext(map[string]string{
-			"metric": "parent_ae_name",
-		}),
+	// The readings for the interface and each of its queues are allocated together.
+	set := newReadingSet(portReadings+queueReadings*(len(iface.GetIngressQueueInfo())+len(iface.GetEgressQueueInfo())), metricFilter(ctx.filters))
+
+	// -*- Bytes Counter Outputs -*-
+	set.add(&outputs.BytesCounter, iface.IngressStats.GetIfOctets(), directionContext("ingress", "if_octets"))
+
+	set.add(&outputs.BytesCounter, iface.EgressStats.GetIfOctets(), directionContext("egress", "if_octets"))
+
+	// -*- Bytes per Second Outputs -*-
+	set.add(&outputs.BytesPerSecond, iface.IngressStats.GetIf_1SecOctets(), directionContext("ingress", "if_1sec_octets"))
+
+	set.add(&outputs.BytesPerSecond, iface.EgressStats.GetIf_1SecOctets(), directionContext("egress", "if_1sec_octets"))
+
+	// -*- Megabits per second Outputs -*-
+	set.add(&outputs.MegabitPerSecond, iface.GetIfHighSpeed(), metricContext("if_high_speed"))
+
+	// -*- Number Outputs -*-
+	set.add(&output.Number, iface.IngressErrors.GetIfInFifoErrors(), directionContext("ingress", "if_in_fifo_errors"))
+	set.add(&output.Number, iface.IngressErrors.GetIfInResourceErrors(), directionContext("ingress", "if_in_resource_errors"))
+	set.add(&output.Number, iface.GetIfTransitions(), metricContext("if_transitions"))
+
+	// -*- Packets Counter Outputs -*-
+	set.add(&outputs.PacketsCounter, iface.IngressStats.GetIfPkts(), directionContext("ingress", "if_pkts"))
+	set.add(&outputs.PacketsCounter, iface.IngressStats.GetIfUcPkts(), directionContext("ingress", "if_uc_pkts"))
+	set.add(&outputs.PacketsCounter, iface.IngressStats.GetIfMcPkts(), directionContext("ingress", "if_mc_pkts"))
+	set.add(&outputs.PacketsCounter, iface.IngressStats.GetIfBcPkts(), directionContext("ingress", "if_bc_pkts"))
+	set.add(&outputs.PacketsCounter, iface.IngressStats.GetIfError(), directionContext("ingress", "if_error"))
+	set.add(&outputs.PacketsCounter, iface.IngressStats.GetIfPausePkts(), directionContext("ingress", "if_pause_pkts"))
+	set.add(&outputs.PacketsCounter, iface.IngressStats.GetIfUnknownProtoPkts(), directionContext("ingress", "if_unknown_proto_pkts"))
+
+	set.add(&outputs.PacketsCounter, iface.EgressStats.GetIfPkts(), directionContext("egress", "if_pkts"))
+	set.add(&outputs.PacketsCounter, iface.EgressStats.GetIfUcPkts(), directionContext("egress", "if_uc_pkts"))
+	set.add(&outputs.PacketsCounter, iface.EgressStats.GetIfMcPkts(), directionContext("egress", "if_mc_pkts"))
+	set.add(&outputs.PacketsCounter, iface.EgressStats.GetIfBcPkts(), directionContext("egress", "if_bc_pkts"))
+	set.add(&outputs.PacketsCounter, iface.EgressStats.GetIfError(), directionContext("egress", "if_error"))
+	set.add(&outputs.PacketsCounter, iface.EgressStats.GetIfPausePkts(), directionContext("egress", "if_pause_pkts"))
+	set.add(&outputs.PacketsCounter, iface.EgressStats.GetIfUnknownProtoPkts(), directionContext("egress", "if_unknown_proto_pkts"))
+
+	set.add(&outputs.PacketsCounter, iface.EgressErrors.GetIfErrors(), directionContext("egress", "if_errors"))
+	set.add(&outputs.PacketsCounter, iface.EgressErrors.GetIfDiscards(), directionContext("egress", "if_discards"))
+
+	set.add(&outputs.PacketsCounter, iface.IngressErrors.GetIfErrors(), directionContext("ingress", "if_errors"))
+	set.add(&outputs.PacketsCounter, iface.IngressErrors.GetIfInQdrops(), directionContext("ingress", "if_in_qdrops"))
+	set.add(&outputs.PacketsCounter, iface.IngressErrors.GetIfInFrameErrors(), directionContext("ingress", "if_in_frame_errors"))
+	set.add(&outputs.PacketsCounter, iface.IngressErrors.GetIfDiscards(), directionContext("ingress", "if_discards"))
+	set.add(&outputs.PacketsCounter, iface.IngressErrors.GetIfInRunts(), directionContext("ingress", "if_in_runts"))
+	set.add(&outputs.PacketsCounter, iface.IngressErrors.GetIfInL3Incompletes(), directionContext("ingress", "if_in_l3_incompletes"))
+	set.add(&outputs.PacketsCounter, iface.IngressErrors.GetIfInL2ChanErrors(), directionContext("ingress", "if_in_l2chan_errors"))
+	set.add(&outputs.PacketsCounter, iface.IngressErrors.GetIfInL2MismatchTimeouts(), directionContext("ingress", "if_in_l2_mismatch_timeouts"))
+
+	// -*- Packets per Second Outputs -*-
+	set.add(&outputs.PacketsPerSecond, iface.IngressStats.GetIf_1SecPkts(), directionContext("ingress", "if_1sec_pkts"))
+
+	set.add(&outputs.PacketsPerSecond, iface.EgressStats.GetIf_1SecPkts(), directionContext("egress", "if_1sec_pkts"))
+
+	// -*- Timestamp Outputs -*-
+	set.add(&output.Timestamp, iface.GetInitTime(), metricContext("init_time"))
+
+	// -*- Time Tick Outputs -*-
+	set.add(&outputs.TimeTicks, iface.GetIfLastChange(), metricContext("if_last_change"))
+
+	// -*- Status Outputs -*-
+	set.add(&output.Status, iface.GetIfAdministrationStatus(), metricContext("if_administration_status"))
+	set.add(&output.Status, iface.GetIfOperationalStatus(), metricContext("if_operational_status"))
+
+	// -*- String Outputs -*-
+	set.add(&output.String, iface.GetIfDescription(), metricContext("if_description"))
+	set.add(&output.String, iface.GetParentAeName(), metricContext("parent_ae_name"))
+
+	// -*- Percent Outputs -*-
+	// Utilization is derived from the 1-second octet rates and the interface speed. It
+	// can only be computed when the interface reports a non-zero speed, so these readings
+	// are omitted otherwise.
+	if pct, ok := utilizationPercent(iface.IngressStats.GetIf_1SecOctets(), iface.GetIfHighSpeed()); ok {
+		set.add(&outputs.Percent, pct, directionContext("ingress", "if_utilization"))
 	}
+	if pct, ok := utilizationPercent(iface.EgressStats.GetIf_1SecOctets(), iface.GetIfHighSpeed()); ok {
+		set.add(&outputs.Percent, pct, directionContext("egress", "if_utilization"))
+	}
+
+	ctx.makeFlapReadings(set, iface)
 
-	var ingresQueueStats []*output.Reading
 	for _, qstat := range iface.GetIngressQueueInfo() {
-		queueNumber := fmt.Sprint(qstat.GetQueueNumber())
-
-		var q = []*output.Reading{
-			outputs.PacketsCounter.MakeReading(qstat.GetPackets()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "packets",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetTailDropPackets()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "tail_drop_packets",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetRlDropPackets()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "rl_drop_packets",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetRedDropPackets()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "red_drop_packets",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetAvgBufferOccupancy()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "avg_buffer_occupancy",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetCurBufferOccupancy()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "cur_buffer_occupancy",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetPeakBufferOccupancy()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "peak_buffer_occupancy",
-			}),
-			outputs.BytesCounter.MakeReading(qstat.GetBytes()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "bytes",
-			}),
-			outputs.BytesCounter.MakeReading(qstat.GetRlDropBytes()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "rl_drop_bytes",
-			}),
-			outputs.BytesCounter.MakeReading(qstat.GetRedDropBytes()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "red_drop_bytes",
-			}),
-			output.Number.MakeReading(qstat.GetAllocatedBufferSize()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "ingress",
-				"metric":       "allocated_buffer_size",
-			}),
-		}
-		ingresQueueStats = append(ingresQueueStats, q...)
+		queue := qstat.GetQueueNumber()
+
+		set.add(&outputs.PacketsCounter, qstat.GetPackets(), queueContext("ingress", queue, "packets"))
+		set.add(&outputs.PacketsCounter, qstat.GetTailDropPackets(), queueContext("ingress", queue, "tail_drop_packets"))
+		set.add(&outputs.PacketsCounter, qstat.GetRlDropPackets(), queueContext("ingress", queue, "rl_drop_packets"))
+		set.add(&outputs.PacketsCounter, qstat.GetRedDropPackets(), queueContext("ingress", queue, "red_drop_packets"))
+		set.add(&outputs.PacketsCounter, qstat.GetAvgBufferOccupancy(), queueContext("ingress", queue, "avg_buffer_occupancy"))
+		set.add(&outputs.PacketsCounter, qstat.GetCurBufferOccupancy(), queueContext("ingress", queue, "cur_buffer_occupancy"))
+		set.add(&outputs.PacketsCounter, qstat.GetPeakBufferOccupancy(), queueContext("ingress", queue, "peak_buffer_occupancy"))
+		set.add(&outputs.BytesCounter, qstat.GetBytes(), queueContext("ingress", queue, "bytes"))
+		set.add(&outputs.BytesCounter, qstat.GetRlDropBytes(), queueContext("ingress", queue, "rl_drop_bytes"))
+		set.add(&outputs.BytesCounter, qstat.GetRedDropBytes(), queueContext("ingress", queue, "red_drop_bytes"))
+		set.add(&output.Number, qstat.GetAllocatedBufferSize(), queueContext("ingress", queue, "allocated_buffer_size"))
 	}
 
-	var egressQueueStats []*output.Reading
 	for _, qstat := range iface.GetEgressQueueInfo() {
-		queueNumber := fmt.Sprint(qstat.GetQueueNumber())
-
-		var q = []*output.Reading{
-			outputs.PacketsCounter.MakeReading(qstat.GetPackets()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "packets",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetTailDropPackets()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "tail_drop_packets",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetRlDropPackets()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "rl_drop_packets",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetRedDropPackets()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "red_drop_packets",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetAvgBufferOccupancy()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "avg_buffer_occupancy",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetCurBufferOccupancy()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "cur_buffer_occupancy",
-			}),
-			outputs.PacketsCounter.MakeReading(qstat.GetPeakBufferOccupancy()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "peak_buffer_occupancy",
-			}),
-			outputs.BytesCounter.MakeReading(qstat.GetBytes()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "bytes",
-			}),
-			outputs.BytesCounter.MakeReading(qstat.GetRlDropBytes()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "rl_drop_bytes",
-			}),
-			outputs.BytesCounter.MakeReading(qstat.GetRedDropBytes()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "red_drop_bytes",
-			}),
-			output.Number.MakeReading(qstat.GetAllocatedBufferSize()).WithContext(map[string]string{
-				"queue_number": queueNumber,
-				"direction":    "egress",
-				"metric":       "allocated_buffer_size",
-			}),
-		}
-		egressQueueStats = append(egressQueueStats, q...)
+		queue := qstat.GetQueueNumber()
+
+		set.add(&outputs.PacketsCounter, qstat.GetPackets(), queueContext("egress", queue, "packets"))
+		set.add(&outputs.PacketsCounter, qstat.GetTailDropPackets(), queueContext("egress", queue, "tail_drop_packets"))
+		set.add(&outputs.PacketsCounter, qstat.GetRlDropPackets(), queueContext("egress", queue, "rl_drop_packets"))
+		set.add(&outputs.PacketsCounter, qstat.GetRedDropPackets(), queueContext("egress", queue, "red_drop_packets"))
+		set.add(&outputs.PacketsCounter, qstat.GetAvgBufferOccupancy(), queueContext("egress", queue, "avg_buffer_occupancy"))
+		set.add(&outputs.PacketsCounter, qstat.GetCurBufferOccupancy(), queueContext("egress", queue, "cur_buffer_occupancy"))
+		set.add(&outputs.PacketsCounter, qstat.GetPeakBufferOccupancy(), queueContext("egress", queue, "peak_buffer_occupancy"))
+		set.add(&outputs.BytesCounter, qstat.GetBytes(), queueContext("egress", queue, "bytes"))
+		set.add(&outputs.BytesCounter, qstat.GetRlDropBytes(), queueContext("egress", queue, "rl_drop_bytes"))
+		set.add(&outputs.BytesCounter, qstat.GetRedDropBytes(), queueContext("egress", queue, "red_drop_bytes"))
+		set.add(&output.Number, qstat.GetAllocatedBufferSize(), queueContext("egress", queue, "allocated_buffer_size"))
 	}
 
-	readings = append(readings, ingresQueueStats...)
-	readings = append(readings, egressQueueStats...)
+	return set.Readings(), nil
+}
 
-	return readings, nil
+// utilizationPercent calculates the utilization of an interface as a percentage of its
+// speed, given the rate of octets over one second and the interface speed in Mbit/s.
+//
+// If the speed is zero (e.g. it is unknown or not reported by the interface), utilization
+// can not be determined and false is returned.
+func utilizationPercent(octetsPerSecond uint64, speedMbps uint32) (float64, bool) {
+	if speedMbps == 0 {
+		return 0, false
+	}
+	bitsPerSecond := float64(octetsPerSecond) * 8
+	return bitsPerSecond / (float64(speedMbps) * 1000 * 1000) * 100, true
 }
@@ -221,7 +221,7 @@ func TestPortContext_MakeReadings(t *testing.T) {
 	readings, err := ctx.MakeReadings(infos)
 	assert.NoError(t, err)
 
-	assert.Len(t, readings, 39)
+	assert.Len(t, readings, 42)
 }
 
 func TestPortContext_MakeReadings2(t *testing.T) {
@@ -250,7 +250,7 @@ func TestPortContext_MakeReadings2(t *testing.T) {
 	readings, err := ctx.MakeReadings(infos)
 	assert.NoError(t, err)
 
-	assert.Len(t, readings, 50)
+	assert.Len(t, readings, 53)
 }
 
 func TestPortContext_MakeReadings3(t *testing.T) {
@@ -279,7 +279,7 @@ func TestPortContext_MakeReadings3(t *testing.T) {
 	readings, err := ctx.MakeReadings(infos)
 	assert.NoError(t, err)
 
-	assert.Len(t, readings, 50)
+	assert.Len(t, readings, 53)
 }
 
 func TestPortContext_MakeReadings4(t *testing.T) {
@@ -311,5 +311,112 @@ func TestPortContext_MakeReadings4(t *testing.T) {
 	readings, err := ctx.MakeReadings(infos)
 	assert.NoError(t, err)
 
-	assert.Len(t, readings, 61)
+	assert.Len(t, readings, 64)
+}
+
+func TestPortContext_MakeReadings_NoSpeed(t *testing.T) {
+	ctx := PortContext{
+		SensorName:     "sensor",
+		SystemID:       "test",
+		ComponentID:    2,
+		SubComponentID: 0,
+	}
+	infos := &port.InterfaceInfos{
+		IfName: &stringVal,
+		IngressStats: &port.InterfaceStats{
+			If_1SecOctets: &uint64Val,
+		},
+	}
+
+	readings, err := ctx.MakeReadings(infos)
+	assert.NoError(t, err)
+
+	// No utilization readings are generated without an interface speed.
+	assert.Len(t, readings, 40)
+	for _, r := range readings {
+		assert.NotEqual(t, "if_utilization", r.Context["metric"])
+	}
+}
+
+func TestPortContext_MakeReadings_Utilization(t *testing.T) {
+	ctx := PortContext{
+		SensorName:     "sensor",
+		SystemID:       "test",
+		ComponentID:    2,
+		SubComponentID: 0,
+	}
+	speed := uint32(1000)
+	ingressOctets := uint64(62500000) // 500 Mbit/s
+	egressOctets := uint64(12500000)  // 100 Mbit/s
+	infos := &port.InterfaceInfos{
+		IfName:      &stringVal,
+		IfHighSpeed: &speed,
+		IngressStats: &port.InterfaceStats{
+			If_1SecOctets: &ingressOctets,
+		},
+		EgressStats: &port.InterfaceStats{
+			If_1SecOctets: &egressOctets,
+		},
+	}
+
+	readings, err := ctx.MakeReadings(infos)
+	assert.NoError(t, err)
+
+	utilization := map[string]interface{}{}
+	for _, r := range readings {
+		if r.Context["metric"] == "if_utilization" {
+			assert.Equal(t, "percentage", r.Type)
+			utilization[r.Context["direction"]] = r.Value
+		}
+	}
+	assert.Equal(t, map[string]interface{}{
+		"ingress": float64(50),
+		"egress":  float64(10),
+	}, utilization)
+}
+
+func Test_utilizationPercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		octets   uint64
+		speed    uint32
+		expected float64
+		ok       bool
+	}{
+		{"zero speed", 100, 0, 0, false},
+		{"zero rate", 0, 1000, 0, true},
+		{"half", 62500000, 1000, 50, true},
+		{"full", 1250000, 10, 100, true},
+		{"over", 2500000, 10, 200, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pct, ok := utilizationPercent(test.octets, test.speed)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, pct)
+		})
+	}
+}
+
+func TestPortContext_MakeDeviceInfo_InterfaceLocation(t *testing.T) {
+	ctx := PortContext{
+		SensorName: "sensor",
+		SystemID:   "test",
+	}
+	name := "et-2/1/3:1"
+
+	info, err := ctx.MakeDeviceInfo(&port.InterfaceInfos{IfName: &name})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"interface_name": "et-2/1/3:1",
+		"system_id":      "test",
+		"metric_type":    "network",
+		"parent_ae_name": "",
+		"media":          "et",
+		"fpc":            "2",
+		"pic":            "1",
+		"port":           "3",
+		"channel":        "1",
+	}, info.Context)
 }
@@ -0,0 +1,405 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.23.0
+// 	protoc        v3.11.4
+// source: opencfg.proto
+
+package opencfg
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type OpenConfigData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SystemId       string      `protobuf:"bytes,1,opt,name=system_id,json=systemId,proto3" json:"system_id,omitempty"`
+	ComponentId    uint32      `protobuf:"varint,2,opt,name=component_id,json=componentId,proto3" json:"component_id,omitempty"`
+	SubComponentId uint32      `protobuf:"varint,3,opt,name=sub_component_id,json=subComponentId,proto3" json:"sub_component_id,omitempty"`
+	Path           string      `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	SequenceNumber uint64      `protobuf:"varint,5,opt,name=sequence_number,json=sequenceNumber,proto3" json:"sequence_number,omitempty"`
+	Timestamp      uint64      `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Kv             []*KeyValue `protobuf:"bytes,7,rep,name=kv,proto3" json:"kv,omitempty"`
+}
+
+func (x *OpenConfigData) Reset() {
+	*x = OpenConfigData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_opencfg_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OpenConfigData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenConfigData) ProtoMessage() {}
+
+func (x *OpenConfigData) ProtoReflect() protoreflect.Message {
+	mi := &file_opencfg_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenConfigData.ProtoReflect.Descriptor instead.
+func (*OpenConfigData) Descriptor() ([]byte, []int) {
+	return file_opencfg_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OpenConfigData) GetSystemId() string {
+	if x != nil {
+		return x.SystemId
+	}
+	return ""
+}
+
+func (x *OpenConfigData) GetComponentId() uint32 {
+	if x != nil {
+		return x.ComponentId
+	}
+	return 0
+}
+
+func (x *OpenConfigData) GetSubComponentId() uint32 {
+	if x != nil {
+		return x.SubComponentId
+	}
+	return 0
+}
+
+func (x *OpenConfigData) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *OpenConfigData) GetSequenceNumber() uint64 {
+	if x != nil {
+		return x.SequenceNumber
+	}
+	return 0
+}
+
+func (x *OpenConfigData) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *OpenConfigData) GetKv() []*KeyValue {
+	if x != nil {
+		return x.Kv
+	}
+	return nil
+}
+
+type KeyValue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Types that are assignable to Value:
+	//	*KeyValue_DoubleValue
+	//	*KeyValue_IntValue
+	//	*KeyValue_UintValue
+	//	*KeyValue_SintValue
+	//	*KeyValue_BoolValue
+	//	*KeyValue_StrValue
+	//	*KeyValue_BytesValue
+	Value isKeyValue_Value `protobuf_oneof:"value"`
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_opencfg_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_opencfg_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_opencfg_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KeyValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (m *KeyValue) GetValue() isKeyValue_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (x *KeyValue) GetDoubleValue() float64 {
+	if x, ok := x.GetValue().(*KeyValue_DoubleValue); ok {
+		return x.DoubleValue
+	}
+	return 0
+}
+
+func (x *KeyValue) GetIntValue() int64 {
+	if x, ok := x.GetValue().(*KeyValue_IntValue); ok {
+		return x.IntValue
+	}
+	return 0
+}
+
+func (x *KeyValue) GetUintValue() uint64 {
+	if x, ok := x.GetValue().(*KeyValue_UintValue); ok {
+		return x.UintValue
+	}
+	return 0
+}
+
+func (x *KeyValue) GetSintValue() int64 {
+	if x, ok := x.GetValue().(*KeyValue_SintValue); ok {
+		return x.SintValue
+	}
+	return 0
+}
+
+func (x *KeyValue) GetBoolValue() bool {
+	if x, ok := x.GetValue().(*KeyValue_BoolValue); ok {
+		return x.BoolValue
+	}
+	return false
+}
+
+func (x *KeyValue) GetStrValue() string {
+	if x, ok := x.GetValue().(*KeyValue_StrValue); ok {
+		return x.StrValue
+	}
+	return ""
+}
+
+func (x *KeyValue) GetBytesValue() []byte {
+	if x, ok := x.GetValue().(*KeyValue_BytesValue); ok {
+		return x.BytesValue
+	}
+	return nil
+}
+
+type isKeyValue_Value interface {
+	isKeyValue_Value()
+}
+
+type KeyValue_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,5,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type KeyValue_IntValue struct {
+	IntValue int64 `protobuf:"varint,6,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type KeyValue_UintValue struct {
+	UintValue uint64 `protobuf:"varint,7,opt,name=uint_value,json=uintValue,proto3,oneof"`
+}
+
+type KeyValue_SintValue struct {
+	SintValue int64 `protobuf:"zigzag64,8,opt,name=sint_value,json=sintValue,proto3,oneof"`
+}
+
+type KeyValue_BoolValue struct {
+	BoolValue bool `protobuf:"varint,9,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type KeyValue_StrValue struct {
+	StrValue string `protobuf:"bytes,10,opt,name=str_value,json=strValue,proto3,oneof"`
+}
+
+type KeyValue_BytesValue struct {
+	BytesValue []byte `protobuf:"bytes,11,opt,name=bytes_value,json=bytesValue,proto3,oneof"`
+}
+
+func (*KeyValue_DoubleValue) isKeyValue_Value() {}
+
+func (*KeyValue_IntValue) isKeyValue_Value() {}
+
+func (*KeyValue_UintValue) isKeyValue_Value() {}
+
+func (*KeyValue_SintValue) isKeyValue_Value() {}
+
+func (*KeyValue_BoolValue) isKeyValue_Value() {}
+
+func (*KeyValue_StrValue) isKeyValue_Value() {}
+
+func (*KeyValue_BytesValue) isKeyValue_Value() {}
+
+var File_opencfg_proto protoreflect.FileDescriptor
+
+var file_opencfg_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x6f, 0x70, 0x65, 0x6e, 0x63, 0x66, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xf0, 0x01, 0x0a, 0x0e, 0x4f, 0x70, 0x65, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x44, 0x61,
+	0x74, 0x61, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x49, 0x64, 0x12,
+	0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74,
+	0x49, 0x64, 0x12, 0x28, 0x0a, 0x10, 0x73, 0x75, 0x62, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
+	0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x73, 0x75,
+	0x62, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x70, 0x61, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x12, 0x27, 0x0a, 0x0f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x6e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x73, 0x65, 0x71, 0x75, 0x65,
+	0x6e, 0x63, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x19, 0x0a, 0x02, 0x6b, 0x76, 0x18, 0x07, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x4b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x02,
+	0x6b, 0x76, 0x22, 0x8e, 0x02, 0x0a, 0x08, 0x4b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12,
+	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
+	0x79, 0x12, 0x23, 0x0a, 0x0c, 0x64, 0x6f, 0x75, 0x62, 0x6c, 0x65, 0x5f, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x0b, 0x64, 0x6f, 0x75, 0x62, 0x6c,
+	0x65, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1d, 0x0a, 0x09, 0x69, 0x6e, 0x74, 0x5f, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x08, 0x69, 0x6e, 0x74,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1f, 0x0a, 0x0a, 0x75, 0x69, 0x6e, 0x74, 0x5f, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x09, 0x75, 0x69, 0x6e,
+	0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1f, 0x0a, 0x0a, 0x73, 0x69, 0x6e, 0x74, 0x5f, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x12, 0x48, 0x00, 0x52, 0x09, 0x73, 0x69,
+	0x6e, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1f, 0x0a, 0x0a, 0x62, 0x6f, 0x6f, 0x6c, 0x5f,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x09, 0x62,
+	0x6f, 0x6f, 0x6c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1d, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x5f,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x08, 0x73,
+	0x74, 0x72, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x21, 0x0a, 0x0b, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x0a,
+	0x62, 0x79, 0x74, 0x65, 0x73, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x42, 0x10, 0x5a, 0x0e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x6f, 0x70,
+	0x65, 0x6e, 0x63, 0x66, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_opencfg_proto_rawDescOnce sync.Once
+	file_opencfg_proto_rawDescData = file_opencfg_proto_rawDesc
+)
+
+func file_opencfg_proto_rawDescGZIP() []byte {
+	file_opencfg_proto_rawDescOnce.Do(func() {
+		file_opencfg_proto_rawDescData = protoimpl.X.CompressGZIP(file_opencfg_proto_rawDescData)
+	})
+	return file_opencfg_proto_rawDescData
+}
+
+var file_opencfg_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_opencfg_proto_goTypes = []interface{}{
+	(*OpenConfigData)(nil), // 0: OpenConfigData
+	(*KeyValue)(nil),       // 1: KeyValue
+}
+var file_opencfg_proto_depIdxs = []int32{
+	1, // 0: OpenConfigData.kv:type_name -> KeyValue
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_opencfg_proto_init() }
+func file_opencfg_proto_init() {
+	if File_opencfg_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_opencfg_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OpenConfigData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_opencfg_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyValue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_opencfg_proto_msgTypes[1].OneofWrappers = []interface{}{
+		(*KeyValue_DoubleValue)(nil),
+		(*KeyValue_IntValue)(nil),
+		(*KeyValue_UintValue)(nil),
+		(*KeyValue_SintValue)(nil),
+		(*KeyValue_BoolValue)(nil),
+		(*KeyValue_StrValue)(nil),
+		(*KeyValue_BytesValue)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_opencfg_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_opencfg_proto_goTypes,
+		DependencyIndexes: file_opencfg_proto_depIdxs,
+		MessageInfos:      file_opencfg_proto_msgTypes,
+	}.Build()
+	File_opencfg_proto = out.File
+	file_opencfg_proto_rawDesc = nil
+	file_opencfg_proto_goTypes = nil
+	file_opencfg_proto_depIdxs = nil
+}
@@ -0,0 +1,168 @@
+package jti
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+	"github.com/vapor-ware/synse-sdk/sdk/utils"
+)
+
+// contextKey identifies a reading context. Readings for the same metric of every
+// device share the same context, so contexts are created once and then reused for
+// every decoded message.
+type contextKey struct {
+	metric    string
+	direction string
+
+	// index is the name of the context key for the queue, lane or window the reading
+	// is for, e.g. "queue_number", and number is its value. If index is empty, the
+	// reading is not indexed.
+	index  string
+	number string
+}
+
+// contextCache holds the shared reading contexts.
+//
+// The contexts it returns are shared between readings, and so must not be modified.
+// A contextCache is safe for concurrent use.
+type contextCache struct {
+	mu       sync.RWMutex
+	contexts map[contextKey]map[string]string
+}
+
+// readingContexts is the cache of reading contexts shared by all decoders.
+var readingContexts = &contextCache{
+	contexts: make(map[contextKey]map[string]string),
+}
+
+// get the context for the given key, creating it if it does not yet exist.
+func (cache *contextCache) get(key contextKey) map[string]string {
+	cache.mu.RLock()
+	ctx, ok := cache.contexts[key]
+	cache.mu.RUnlock()
+	if ok {
+		return ctx
+	}
+
+	ctx = map[string]string{
+		"metric": key.metric,
+	}
+	if key.direction != "" {
+		ctx["direction"] = key.direction
+	}
+	if key.index != "" {
+		ctx[key.index] = key.number
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if existing, ok := cache.contexts[key]; ok {
+		return existing
+	}
+	cache.contexts[key] = ctx
+	return ctx
+}
+
+// metricContext gets the shared context for readings of a metric.
+func metricContext(metric string) map[string]string {
+	return readingContexts.get(contextKey{metric: metric})
+}
+
+// directionContext gets the shared context for readings of a metric in the given
+// traffic direction.
+func directionContext(direction, metric string) map[string]string {
+	return readingContexts.get(contextKey{metric: metric, direction: direction})
+}
+
+// queueContext gets the shared context for readings of a queue metric in the given
+// traffic direction.
+func queueContext(direction string, queue uint32, metric string) map[string]string {
+	return readingContexts.get(contextKey{metric: metric, direction: direction, index: "queue_number", number: formatNumber(queue)})
+}
+
+// laneContext gets the shared context for readings of an optics lane metric.
+func laneContext(lane uint32, metric string) map[string]string {
+	return readingContexts.get(contextKey{metric: metric, index: "lane_number", number: formatNumber(lane)})
+}
+
+// windowContext gets the shared context for readings of a metric over a window, given
+// the formatted window.
+func windowContext(window, metric string) map[string]string {
+	return readingContexts.get(contextKey{metric: metric, index: "window", number: window})
+}
+
+// formatNumber formats a queue or lane number. Formatting small numbers does not
+// allocate, so it is cheap enough to do for every reading.
+func formatNumber(n uint32) string {
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+// readingTemplates holds a reading for each output which is used as the template
+// for the readings of that output. Readings must be created by their output so that
+// they reference it, which the SDK needs in order to report the outputs of a device.
+var readingTemplates sync.Map
+
+// readingSet builds the readings for a device. The readings are allocated together
+// and share the same timestamp, rather than being allocated and timestamped one by
+// one.
+//
+// The context of each reading is shared, so it must not be modified.
+type readingSet struct {
+	timestamp string
+	readings  []output.Reading
+
+	// metricFilter filters the readings by their metric, so that readings for
+	// excluded metrics are not built. If nil, no readings are filtered.
+	metricFilter *config.Filter
+}
+
+// newReadingSet creates a new readingSet with capacity for the given number of
+// readings, which only holds the readings allowed by the metric filter.
+func newReadingSet(size int, metricFilter *config.Filter) *readingSet {
+	return &readingSet{
+		timestamp:    utils.GetCurrentTime(),
+		readings:     make([]output.Reading, 0, size),
+		metricFilter: metricFilter,
+	}
+}
+
+// allows checks whether the metric filter allows a reading with the given context,
+// counting the reading if it is filtered.
+func (set *readingSet) allows(ctx map[string]string) bool {
+	if set.metricFilter == nil || set.metricFilter.Allows(ctx["metric"]) {
+		return true
+	}
+	metrics.Filtered.WithLabelValues("metric").Inc()
+	return false
+}
+
+// add a reading for an output with the given value and shared context. If the
+// reading's metric is filtered, the reading is not added.
+func (set *readingSet) add(o *output.Output, value interface{}, ctx map[string]string) {
+	if !set.allows(ctx) {
+		return
+	}
+
+	tmpl, ok := readingTemplates.Load(o)
+	if !ok {
+		tmpl, _ = readingTemplates.LoadOrStore(o, o.MakeReading(nil))
+	}
+
+	reading := *tmpl.(*output.Reading)
+	reading.Timestamp = set.timestamp
+	reading.Value = value
+	reading.Context = ctx
+	set.readings = append(set.readings, reading)
+}
+
+// Readings gets the readings in the set.
+func (set *readingSet) Readings() []*output.Reading {
+	readings := make([]*output.Reading, len(set.readings))
+	for i := range set.readings {
+		readings[i] = &set.readings[i]
+	}
+	return readings
+}
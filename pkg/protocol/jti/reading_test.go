@@ -0,0 +1,61 @@
+package jti
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/outputs"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+func TestContextCache_get(t *testing.T) {
+	cache := &contextCache{contexts: make(map[contextKey]map[string]string)}
+
+	ctx := cache.get(contextKey{metric: "if_octets"})
+	assert.Equal(t, map[string]string{"metric": "if_octets"}, ctx)
+
+	ctx = cache.get(contextKey{metric: "packets", direction: "egress", index: "queue_number", number: "3"})
+	assert.Equal(t, map[string]string{
+		"metric":       "packets",
+		"direction":    "egress",
+		"queue_number": "3",
+	}, ctx)
+
+	// The same context is returned for the same key.
+	ctx["test"] = "shared"
+	assert.Equal(t, "shared", cache.get(contextKey{metric: "packets", direction: "egress", index: "queue_number", number: "3"})["test"])
+	assert.Len(t, cache.contexts, 2)
+}
+
+func TestReadingContexts(t *testing.T) {
+	assert.Equal(t, map[string]string{"metric": "init_time"}, metricContext("init_time"))
+	assert.Equal(t, map[string]string{"metric": "if_pkts", "direction": "ingress"}, directionContext("ingress", "if_pkts"))
+	assert.Equal(t, map[string]string{"metric": "bytes", "direction": "egress", "queue_number": "7"}, queueContext("egress", 7, "bytes"))
+	assert.Equal(t, map[string]string{"metric": "lane_laser_temperature", "lane_number": "2"}, laneContext(2, "lane_laser_temperature"))
+	assert.Equal(t, map[string]string{"metric": "if_flaps", "window": "5m"}, windowContext("5m", "if_flaps"))
+}
+
+func TestReadingSet(t *testing.T) {
+	set := newReadingSet(2, nil)
+	set.add(&outputs.BytesCounter, uint64(1000), directionContext("ingress", "if_octets"))
+	set.add(&outputs.BytesCounter, uint64(2000), directionContext("egress", "if_octets"))
+	set.add(&output.Status, "ok", metricContext("status"))
+
+	readings := set.Readings()
+	assert.Len(t, readings, 3)
+
+	assert.Equal(t, uint64(1000), readings[0].Value)
+	assert.Equal(t, outputs.BytesCounter.Type, readings[0].Type)
+	assert.Equal(t, outputs.BytesCounter.Unit, readings[0].Unit)
+	assert.Equal(t, &outputs.BytesCounter, readings[0].GetOutput())
+	assert.Equal(t, set.timestamp, readings[0].Timestamp)
+	assert.Equal(t, map[string]string{"metric": "if_octets", "direction": "ingress"}, readings[0].Context)
+
+	assert.Equal(t, uint64(2000), readings[1].Value)
+	assert.Equal(t, set.timestamp, readings[1].Timestamp)
+	assert.Equal(t, map[string]string{"metric": "if_octets", "direction": "egress"}, readings[1].Context)
+
+	assert.Equal(t, "ok", readings[2].Value)
+	assert.Equal(t, &output.Status, readings[2].GetOutput())
+	assert.Equal(t, map[string]string{"metric": "status"}, readings[2].Context)
+}
@@ -0,0 +1,263 @@
+package jti
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+)
+
+// sourceComponents are the ID components which identify the source of a sample, rather
+// than the sample itself. They are excluded from the key of a sample so that the same
+// sample from different sources has the same key.
+var sourceComponents = map[string]bool{
+	"sys":  true,
+	"cid":  true,
+	"scid": true,
+}
+
+// sampleExpiry is the minimum duration for which no data may be received for a sample
+// before it is no longer tracked. Once its active source is stale, a sample behaves as
+// if it were not tracked, so this only needs to be long enough that short outages are
+// still counted as switchovers.
+const sampleExpiry = 5 * time.Minute
+
+// sampleKey identifies a sample of a sensor, regardless of which source exports it.
+type sampleKey struct {
+	hostname string
+	sensor   string
+	key      string
+}
+
+// sampleSource holds the state of the active source of a sample.
+type sampleSource struct {
+	source    string
+	sequence  uint32
+	timestamp uint64
+	seen      time.Time
+}
+
+// newer checks whether a sample with the given sequence number and timestamp is newer
+// than the data last received from the source. Either being greater is sufficient, so
+// that a source whose sequence numbers were reset, e.g. because it restarted, is not
+// considered stale.
+func (s *sampleSource) newer(sequence uint32, timestamp uint64) bool {
+	return sequence > s.sequence || timestamp > s.timestamp
+}
+
+// redundancyTracker de-duplicates the samples of redundant streams, e.g. from both
+// routing engines of a router, or the same stream forwarded by multiple collectors.
+//
+// Samples are keyed by the hostname and sensor path of their stream and the sample's
+// own key (e.g. the interface name). For each sample, a single source is active, and
+// the samples from all other sources are dropped. Samples from the active source which
+// are not newer than its previous data, by sequence number and timestamp, are dropped
+// as duplicates. If no new data is received from the active source within the failover
+// timeout, the next source to export the sample takes over. Samples for which no data is
+// received within the expiry, the longer of sampleExpiry and the failover timeout, are
+// no longer tracked.
+//
+// A redundancyTracker is safe for concurrent use.
+type redundancyTracker struct {
+	mu       sync.Mutex
+	failover time.Duration
+	expiry   time.Duration
+	samples  map[sampleKey]*sampleSource
+
+	// swept is the time at which expired samples were last evicted.
+	swept time.Time
+
+	// now gets the current time. It is defined on the tracker so it may be
+	// overridden for testing.
+	now func() time.Time
+}
+
+// newRedundancyTracker creates a new redundancyTracker with the given failover timeout.
+func newRedundancyTracker(failover time.Duration) *redundancyTracker {
+	expiry := sampleExpiry
+	if failover > expiry {
+		expiry = failover
+	}
+	return &redundancyTracker{
+		failover: failover,
+		expiry:   expiry,
+		samples:  make(map[sampleKey]*sampleSource),
+		now:      time.Now,
+	}
+}
+
+// streamSamples checks the samples of a single TelemetryStream message against a
+// redundancyTracker. A nil streamSamples allows all samples.
+type streamSamples struct {
+	tracker   *redundancyTracker
+	hostname  string
+	sensor    string
+	source    string
+	sequence  uint32
+	timestamp uint64
+
+	// allowed holds the samples which have been checked. A message may hold more
+	// than one container for the same sample, so each sample is only checked once
+	// per message.
+	allowed map[string]bool
+}
+
+// Stream gets the samples of a TelemetryStream message, so they can be checked before
+// they are decoded. If the tracker is nil, nil is returned, which allows all samples.
+func (tracker *redundancyTracker) Stream(ts *telemetry_top.TelemetryStream) *streamSamples {
+	if tracker == nil {
+		return nil
+	}
+
+	info := NewStreamInfo(ts)
+	return &streamSamples{
+		tracker:   tracker,
+		hostname:  info.Hostname,
+		sensor:    info.SensorPath,
+		source:    streamSource(ts),
+		sequence:  ts.GetSequenceNumber(),
+		timestamp: ts.GetTimestamp(),
+		allowed:   map[string]bool{},
+	}
+}
+
+// Allow checks whether the data of the sample with the given key should be used.
+func (samples *streamSamples) Allow(key string) bool {
+	if samples == nil {
+		return true
+	}
+
+	allow, checked := samples.allowed[key]
+	if !checked {
+		allow = samples.tracker.Allow(sampleKey{
+			hostname: samples.hostname,
+			sensor:   samples.sensor,
+			key:      key,
+		}, samples.source, samples.sequence, samples.timestamp)
+		samples.allowed[key] = allow
+	}
+	return allow
+}
+
+// Filter drops the decoded data of a TelemetryStream message which is not from the
+// active source of its sample, or which is a duplicate.
+//
+// This is used for the data of decoders which do not check their samples as they
+// decode them. If the tracker is nil, nothing is dropped.
+func (tracker *redundancyTracker) Filter(ts *telemetry_top.TelemetryStream, decoded []*IntermediaryDataContainer) []*IntermediaryDataContainer {
+	samples := tracker.Stream(ts)
+	if samples == nil {
+		return decoded
+	}
+
+	filtered := decoded[:0]
+	for _, d := range decoded {
+		if samples.Allow(makeSampleKey(d.DeviceInfo)) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// Allow checks whether the data of a sample from a source should be used, updating the
+// active source of the sample.
+func (tracker *redundancyTracker) Allow(key sampleKey, source string, sequence uint32, timestamp uint64) bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	now := tracker.now()
+	tracker.evict(now)
+
+	active, exists := tracker.samples[key]
+	if !exists {
+		tracker.samples[key] = &sampleSource{
+			source:    source,
+			sequence:  sequence,
+			timestamp: timestamp,
+			seen:      now,
+		}
+		metrics.ActiveSources.WithLabelValues(key.hostname, key.sensor, source).Inc()
+		return true
+	}
+
+	stale := now.Sub(active.seen) > tracker.failover
+	if active.source == source {
+		if !stale && !active.newer(sequence, timestamp) {
+			metrics.RedundantSamples.WithLabelValues("duplicate").Inc()
+			return false
+		}
+	} else {
+		if !stale {
+			metrics.RedundantSamples.WithLabelValues("standby").Inc()
+			return false
+		}
+
+		log.WithFields(log.Fields{
+			"hostname": key.hostname,
+			"sensor":   key.sensor,
+			"key":      key.key,
+			"from":     active.source,
+			"to":       source,
+		}).Debug("[jti] active source of sample is stale - switching over")
+		metrics.Switchovers.WithLabelValues(key.hostname, key.sensor).Inc()
+		metrics.ActiveSources.WithLabelValues(key.hostname, key.sensor, active.source).Dec()
+		metrics.ActiveSources.WithLabelValues(key.hostname, key.sensor, source).Inc()
+		active.source = source
+	}
+
+	active.sequence = sequence
+	active.timestamp = timestamp
+	active.seen = now
+	return true
+}
+
+// evict the samples which have not been seen within the expiry. Samples are swept at
+// most once per expiry, so the cost of the sweep is spread across many updates.
+//
+// The caller must hold the tracker's lock.
+func (tracker *redundancyTracker) evict(now time.Time) {
+	if now.Sub(tracker.swept) < tracker.expiry {
+		return
+	}
+	tracker.swept = now
+
+	for key, active := range tracker.samples {
+		if now.Sub(active.seen) > tracker.expiry {
+			metrics.ActiveSources.WithLabelValues(key.hostname, key.sensor, active.source).Dec()
+			delete(tracker.samples, key)
+		}
+	}
+}
+
+// streamSource gets the source of a TelemetryStream message, identified by its system ID
+// and the component which exports it.
+func streamSource(ts *telemetry_top.TelemetryStream) string {
+	return fmt.Sprintf("%s/%d/%d", ts.GetSystemId(), ts.GetComponentId(), ts.GetSubComponentId())
+}
+
+// makeSampleKey gets the key of the sample which a DeviceInfo was decoded from, made up of
+// the device type and its ID components which do not identify the source.
+func makeSampleKey(info *DeviceInfo) string {
+	keys := make([]string, 0, len(info.IDComponents))
+	for k := range info.IDComponents {
+		if !sourceComponents[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(info.Type)
+	for _, k := range keys {
+		b.WriteString("|")
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(info.IDComponents[k])
+	}
+	return b.String()
+}
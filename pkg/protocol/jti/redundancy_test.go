@@ -0,0 +1,195 @@
+package jti
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+	"google.golang.org/protobuf/runtime/protoimpl"
+)
+
+func newTestRedundancyTracker(clock *testClock) *redundancyTracker {
+	tracker := newRedundancyTracker(30 * time.Second)
+	tracker.now = clock.now
+	return tracker
+}
+
+func TestRedundancyTracker_Allow_Duplicates(t *testing.T) {
+	clock := newTestClock()
+	tracker := newTestRedundancyTracker(clock)
+	key := sampleKey{hostname: "dup-router", sensor: "/junos/system/linecard/interface/", key: "interface|if=et-0/0/0"}
+	duplicates := testutil.ToFloat64(metrics.RedundantSamples.WithLabelValues("duplicate"))
+
+	assert.True(t, tracker.Allow(key, "re0", 10, 1000))
+	assert.False(t, tracker.Allow(key, "re0", 10, 1000), "same sample")
+	assert.False(t, tracker.Allow(key, "re0", 9, 900), "older sample")
+	assert.True(t, tracker.Allow(key, "re0", 11, 1000), "newer sequence number")
+	assert.True(t, tracker.Allow(key, "re0", 0, 2000), "newer timestamp after sequence reset")
+	assert.Equal(t, duplicates+2, testutil.ToFloat64(metrics.RedundantSamples.WithLabelValues("duplicate")))
+
+	// Once the source is stale, its data is used again even if it is not newer, so a
+	// source which restarted without timestamps does not remain stale.
+	clock.advance(time.Minute)
+	assert.True(t, tracker.Allow(key, "re0", 0, 0))
+}
+
+func TestRedundancyTracker_Allow_Switchover(t *testing.T) {
+	clock := newTestClock()
+	tracker := newTestRedundancyTracker(clock)
+	sensor := "/junos/system/linecard/interface/"
+	key := sampleKey{hostname: "switch-router", sensor: sensor, key: "interface|if=et-0/0/0"}
+
+	// The first source to export the sample is active, and the data from other
+	// sources is dropped while the active source is fresh.
+	assert.True(t, tracker.Allow(key, "re0", 1, 1000))
+	clock.advance(10 * time.Second)
+	assert.False(t, tracker.Allow(key, "re1", 50, 11000))
+	assert.True(t, tracker.Allow(key, "re0", 2, 11000))
+	clock.advance(30 * time.Second)
+	assert.False(t, tracker.Allow(key, "re1", 51, 41000))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ActiveSources.WithLabelValues("switch-router", sensor, "re0")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.Switchovers.WithLabelValues("switch-router", sensor)))
+
+	// Once the active source is stale, the next source takes over.
+	clock.advance(time.Second)
+	assert.True(t, tracker.Allow(key, "re1", 52, 42000))
+	assert.False(t, tracker.Allow(key, "re0", 3, 43000))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.ActiveSources.WithLabelValues("switch-router", sensor, "re0")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ActiveSources.WithLabelValues("switch-router", sensor, "re1")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Switchovers.WithLabelValues("switch-router", sensor)))
+}
+
+func TestRedundancyTracker_Allow_SeparateKeys(t *testing.T) {
+	clock := newTestClock()
+	tracker := newTestRedundancyTracker(clock)
+
+	assert.True(t, tracker.Allow(sampleKey{hostname: "r1", sensor: "s", key: "a"}, "re0", 1, 1000))
+	assert.True(t, tracker.Allow(sampleKey{hostname: "r1", sensor: "s", key: "b"}, "re1", 1, 1000))
+	assert.True(t, tracker.Allow(sampleKey{hostname: "r1", sensor: "t", key: "a"}, "re1", 1, 1000))
+	assert.True(t, tracker.Allow(sampleKey{hostname: "r2", sensor: "s", key: "a"}, "re1", 1, 1000))
+}
+
+func TestRedundancyTracker_Allow_Evicts(t *testing.T) {
+	clock := newTestClock()
+	tracker := newTestRedundancyTracker(clock)
+	assert.Equal(t, sampleExpiry, tracker.expiry)
+
+	old := sampleKey{hostname: "evict-router", sensor: "s", key: "old"}
+	recent := sampleKey{hostname: "evict-router", sensor: "s", key: "recent"}
+	assert.True(t, tracker.Allow(old, "re0", 1, 1000))
+	clock.advance(sampleExpiry / 2)
+	assert.True(t, tracker.Allow(recent, "re0", 1, 1000))
+
+	// Samples which have not been seen within the expiry are evicted, along with
+	// their active source.
+	clock.advance(sampleExpiry/2 + time.Second)
+	assert.True(t, tracker.Allow(recent, "re0", 2, 2000))
+	assert.NotContains(t, tracker.samples, old)
+	assert.Contains(t, tracker.samples, recent)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ActiveSources.WithLabelValues("evict-router", "s", "re0")))
+
+	// The expiry is never shorter than the failover timeout.
+	assert.Equal(t, time.Hour, newRedundancyTracker(time.Hour).expiry)
+}
+
+func TestRedundancyTracker_Stream_Nil(t *testing.T) {
+	var tracker *redundancyTracker
+	samples := tracker.Stream(&telemetry_top.TelemetryStream{})
+	assert.Nil(t, samples)
+	assert.True(t, samples.Allow("et-0/0/0"))
+
+	decoded := []*IntermediaryDataContainer{{DeviceInfo: &DeviceInfo{Type: "interface"}}}
+	assert.Equal(t, decoded, tracker.Filter(&telemetry_top.TelemetryStream{}, decoded))
+}
+
+func Test_makeSampleKey(t *testing.T) {
+	key := makeSampleKey(&DeviceInfo{
+		Type: "optic",
+		IDComponents: map[string]string{
+			"sys":  "re0-router1:10.1.1.1",
+			"if":   "et-0/0/0",
+			"lane": "1",
+			"cid":  "0",
+			"scid": "1",
+		},
+	})
+	assert.Equal(t, "optic|if=et-0/0/0|lane=1", key)
+
+	// Samples from other sources have the same key.
+	assert.Equal(t, key, makeSampleKey(&DeviceInfo{
+		Type: "optic",
+		IDComponents: map[string]string{
+			"sys":  "re1-router1:10.1.1.2",
+			"if":   "et-0/0/0",
+			"lane": "1",
+			"cid":  "1",
+			"scid": "0",
+		},
+	}))
+}
+
+// makeSequencedStream creates the encoded bytes for a TelemetryStream message with port
+// data for the given interfaces, with the given sequence number and timestamp.
+func makeSequencedStream(t *testing.T, systemID string, sequence uint32, timestamp uint64, names ...string) []byte {
+	ts := &telemetry_top.TelemetryStream{}
+	assert.NoError(t, proto.Unmarshal(makeStream(t, systemID, "sensor_1000:/junos/system/linecard/interface/:/junos/system/linecard/interface/:PFE", map[*protoimpl.ExtensionInfo]interface{}{
+		port.E_JnprInterfaceExt: makePort(names...),
+	}), ts))
+	ts.SequenceNumber = &sequence
+	ts.Timestamp = &timestamp
+
+	b, err := proto.Marshal(ts)
+	assert.NoError(t, err)
+	return b
+}
+
+func TestJuniperJTIDecoder_Decode_Redundancy(t *testing.T) {
+	decoder := NewJTIDecoder(&config.ServerConfig{
+		Redundancy: config.RedundancyConfig{Enabled: true},
+	}, manager.NewStubDeviceManager(false))
+	assert.Equal(t, config.DefaultFailover, decoder.redundancy.failover)
+
+	data, err := decoder.Decode(makeSequencedStream(t, "re0-dedup:10.1.1.1", 1, 1000, "et-0/0/0", "et-0/0/1"))
+	assert.NoError(t, err)
+	assert.Len(t, data, 2)
+
+	// The same data forwarded by another collector is a duplicate.
+	data, err = decoder.Decode(makeSequencedStream(t, "re0-dedup:10.1.1.1", 1, 1000, "et-0/0/0", "et-0/0/1"))
+	assert.NoError(t, err)
+	assert.Len(t, data, 0)
+
+	// The other routing engine is only active for the samples which the active
+	// routing engine does not export.
+	data, err = decoder.Decode(makeSequencedStream(t, "re1-dedup:10.1.1.1", 7, 1500, "et-0/0/1", "et-0/0/2"))
+	assert.NoError(t, err)
+	if assert.Len(t, data, 1) {
+		assert.Equal(t, "et-0/0/2", data[0].DeviceInfo.IDComponents["if"])
+		assert.Equal(t, "re1", data[0].DeviceInfo.Context["routing_engine"])
+	}
+
+	// The data which is dropped does not update the trackers.
+	assert.Nil(t, decoder.interfaces.Lookup("re1-dedup:10.1.1.1", 0, "et-0/0/1"))
+	assert.NotNil(t, decoder.interfaces.Lookup("re1-dedup:10.1.1.1", 0, "et-0/0/2"))
+
+	data, err = decoder.Decode(makeSequencedStream(t, "re0-dedup:10.1.1.1", 2, 2000, "et-0/0/0", "et-0/0/1"))
+	assert.NoError(t, err)
+	assert.Len(t, data, 2)
+}
+
+func TestJuniperJTIDecoder_Decode_RedundancyDisabled(t *testing.T) {
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false))
+	assert.Nil(t, decoder.redundancy)
+
+	for i := 0; i < 2; i++ {
+		data, err := decoder.Decode(makeSequencedStream(t, "re0-nodedup:10.1.1.1", 1, 1000, "et-0/0/0"))
+		assert.NoError(t, err)
+		assert.Len(t, data, 1)
+	}
+}
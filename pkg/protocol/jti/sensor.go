@@ -0,0 +1,81 @@
+package jti
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// SensorDecoder decodes a Juniper sensor extension into data containers which can be
+// translated into Synse devices and readings.
+//
+// Sensor extensions extend the JuniperNetworksSensors message. The Go package which
+// defines the extension must be imported so that the extension is registered with the
+// protobuf runtime, otherwise it can not be parsed from received messages.
+type SensorDecoder interface {
+	// Extension gets the descriptor of the sensor extension which is decoded.
+	Extension() protoreflect.ExtensionType
+
+	// Decode the sensor extension message from a TelemetryStream.
+	//
+	// The TelemetryStream and the sensor message are reused once decoding completes,
+	// so they must not be retained by the decoder or by the data it returns.
+	Decode(ts *telemetry_top.TelemetryStream, msg proto.Message) ([]*IntermediaryDataContainer, error)
+}
+
+// sensorDecoder is a SensorDecoder which is defined by a decode function.
+type sensorDecoder struct {
+	extension protoreflect.ExtensionType
+	decode    func(ts *telemetry_top.TelemetryStream, msg proto.Message) ([]*IntermediaryDataContainer, error)
+}
+
+// NewSensorDecoder creates a SensorDecoder for a sensor extension which is decoded by
+// the given function.
+func NewSensorDecoder(extension protoreflect.ExtensionType, decode func(ts *telemetry_top.TelemetryStream, msg proto.Message) ([]*IntermediaryDataContainer, error)) SensorDecoder {
+	return &sensorDecoder{
+		extension: extension,
+		decode:    decode,
+	}
+}
+
+// Extension gets the descriptor of the sensor extension which is decoded.
+func (d *sensorDecoder) Extension() protoreflect.ExtensionType {
+	return d.extension
+}
+
+// Decode the sensor extension message from a TelemetryStream.
+func (d *sensorDecoder) Decode(ts *telemetry_top.TelemetryStream, msg proto.Message) ([]*IntermediaryDataContainer, error) {
+	return d.decode(ts, msg)
+}
+
+// ValidateSensorDecoders checks that decoders can be used for sensor extensions which
+// are not supported by the plugin itself.
+//
+// An error is returned if a decoder does not decode an extension of the
+// JuniperNetworksSensors message, if the plugin supports its extension itself, or if
+// more than one of the decoders is for the same extension.
+func ValidateSensorDecoders(decoders ...SensorDecoder) error {
+	jns := (&telemetry_top.JuniperNetworksSensors{}).ProtoReflect().Descriptor().FullName()
+	for i, d := range decoders {
+		ext := d.Extension()
+		if ext == nil {
+			return fmt.Errorf("sensor decoder does not define an extension")
+		}
+		desc := ext.TypeDescriptor()
+		if desc.ContainingMessage().FullName() != jns {
+			return fmt.Errorf("sensor extension %s does not extend %s", desc.FullName(), jns)
+		}
+
+		if isBuiltinSensor(ext) {
+			return fmt.Errorf("sensor extension %s is already supported by the plugin", desc.FullName())
+		}
+		for _, other := range decoders[:i] {
+			if other.Extension() == ext {
+				return fmt.Errorf("sensor decoder given more than once for extension %s", desc.FullName())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package jti
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/runtime/protoimpl"
+)
+
+// testSensorExt is a sensor extension which is not supported by the plugin.
+var testSensorExt = &protoimpl.ExtensionInfo{
+	ExtendedType:  (*telemetry_top.JuniperNetworksSensors)(nil),
+	ExtensionType: (*port.Port)(nil),
+	Field:         1000,
+	Name:          "jnpr_test_ext",
+	Tag:           "bytes,1000,opt,name=jnpr_test_ext",
+	Filename:      "test.proto",
+}
+
+func init() {
+	if err := protoregistry.GlobalTypes.RegisterExtension(testSensorExt); err != nil {
+		panic(err)
+	}
+}
+
+func TestNewJTIDecoder_SensorDecoders(t *testing.T) {
+	var decoded *port.Port
+	sensor := NewSensorDecoder(testSensorExt, func(ts *telemetry_top.TelemetryStream, msg proto.Message) ([]*IntermediaryDataContainer, error) {
+		decoded = msg.(*port.Port)
+		return []*IntermediaryDataContainer{{
+			DeviceInfo: &DeviceInfo{
+				Type:    "test",
+				Context: map[string]string{},
+			},
+		}}, nil
+	})
+	assert.NoError(t, ValidateSensorDecoders(sensor))
+
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false), sensor)
+	data, err := decoder.Decode(makeStream(t, "router1", "sensor", map[*protoimpl.ExtensionInfo]interface{}{
+		testSensorExt:           makePort("xe-0/0/0"),
+		port.E_JnprInterfaceExt: makePort("et-0/0/0"),
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, data, 2)
+	assert.Equal(t, "interface", data[0].DeviceInfo.Type)
+	assert.Equal(t, "test", data[1].DeviceInfo.Type)
+	assert.Equal(t, "router1", data[1].DeviceInfo.Context["hostname"])
+	assert.Equal(t, "xe-0/0/0", decoded.GetInterfaceStats()[0].GetIfName())
+
+	// The sensor decoders are only used by the decoder they are given to.
+	data, err = NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false)).Decode(makeStream(t, "router1", "sensor", map[*protoimpl.ExtensionInfo]interface{}{
+		testSensorExt: makePort("xe-0/0/0"),
+	}))
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestNewJTIDecoder_SensorDecodersBuiltin(t *testing.T) {
+	// Decoders for the plugin's own extensions do not replace the plugin's decoders.
+	decoder := NewJTIDecoder(&config.ServerConfig{}, manager.NewStubDeviceManager(false), NewSensorDecoder(port.E_JnprInterfaceExt, nil))
+	data, err := decoder.Decode(makeStream(t, "router1", "sensor", map[*protoimpl.ExtensionInfo]interface{}{
+		port.E_JnprInterfaceExt: makePort("et-0/0/0"),
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+}
+
+func TestValidateSensorDecoders(t *testing.T) {
+	assert.NoError(t, ValidateSensorDecoders())
+	assert.NoError(t, ValidateSensorDecoders(NewSensorDecoder(testSensorExt, nil)))
+
+	// The same decoders may be validated, and used, more than once.
+	assert.NoError(t, ValidateSensorDecoders(NewSensorDecoder(testSensorExt, nil)))
+}
+
+func TestValidateSensorDecoders_ErrDuplicate(t *testing.T) {
+	assert.Error(t, ValidateSensorDecoders(
+		NewSensorDecoder(testSensorExt, nil),
+		NewSensorDecoder(testSensorExt, nil),
+	))
+}
+
+func TestValidateSensorDecoders_ErrBuiltin(t *testing.T) {
+	assert.Error(t, ValidateSensorDecoders(NewSensorDecoder(port.E_JnprInterfaceExt, nil)))
+	assert.Error(t, ValidateSensorDecoders(
+		NewSensorDecoder(testSensorExt, nil),
+		NewSensorDecoder(port.E_JnprInterfaceExt, nil),
+	))
+}
+
+func TestValidateSensorDecoders_ErrNotSensor(t *testing.T) {
+	// The Juniper Networks extension extends EnterpriseSensors, not JuniperNetworksSensors.
+	assert.Error(t, ValidateSensorDecoders(NewSensorDecoder(telemetry_top.E_JuniperNetworks, nil)))
+	assert.Error(t, ValidateSensorDecoders(NewSensorDecoder(nil, nil)))
+}
@@ -0,0 +1,155 @@
+package jti
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+)
+
+// routingEngine matches a routing engine identifier (e.g. "re0", "re1") at either
+// the start or the end of a hostname.
+var routingEngine = regexp.MustCompile(`^(re\d+)-(.+)$|^(.+)-(re\d+)$`)
+
+// StreamInfo holds the structured information which Junos encodes into the system ID
+// and sensor name of a TelemetryStream message.
+//
+// The system ID is formatted as "<hostname>:<management IP>", where the hostname may
+// be qualified with the routing engine which is sending the data, e.g.
+// "re0-router1:10.1.1.1".
+//
+// The sensor name is formatted as "<subscription>:<internal path>:<sensor path>:<producer>",
+// e.g. "sensor_1000_4_1:/junos/system/linecard/interface/:/junos/system/linecard/interface/:PFE".
+type StreamInfo struct {
+	Hostname      string
+	ManagementIP  string
+	RoutingEngine string
+	Subscription  string
+	SensorPath    string
+	Producer      string
+}
+
+// NewStreamInfo creates a new StreamInfo from a TelemetryStream message.
+func NewStreamInfo(ts *telemetry_top.TelemetryStream) *StreamInfo {
+	info := &StreamInfo{}
+	info.parseSystemID(ts.GetSystemId())
+	info.parseSensorName(ts.GetSensorName())
+	return info
+}
+
+// parseSystemID parses the hostname, management IP, and routing engine from a system ID.
+// Any components which are not present or can not be parsed are left empty.
+func (info *StreamInfo) parseSystemID(systemID string) {
+	hostname := systemID
+
+	// The hostname is everything before the first colon. The remainder is the
+	// management IP, which may itself contain colons if it is an IPv6 address.
+	if idx := strings.Index(systemID, ":"); idx >= 0 {
+		if ip := net.ParseIP(systemID[idx+1:]); ip != nil {
+			info.ManagementIP = ip.String()
+		}
+		hostname = systemID[:idx]
+	}
+
+	if m := routingEngine.FindStringSubmatch(hostname); m != nil {
+		if m[1] != "" {
+			info.RoutingEngine, hostname = m[1], m[2]
+		} else {
+			info.RoutingEngine, hostname = m[4], m[3]
+		}
+	}
+	info.Hostname = hostname
+}
+
+// SystemHostname gets the hostname of a system ID, without its management IP or the
+// routing engine which qualifies it, e.g. "router1" for "re0-router1:10.1.1.1".
+func SystemHostname(systemID string) string {
+	info := &StreamInfo{}
+	info.parseSystemID(systemID)
+	return info.Hostname
+}
+
+// parseSensorName parses the subscription name, sensor path, and producer from a
+// sensor name. Any components which are not present are left empty.
+func (info *StreamInfo) parseSensorName(sensorName string) {
+	if sensorName == "" {
+		return
+	}
+
+	parts := strings.Split(sensorName, ":")
+	info.Subscription = parts[0]
+
+	switch len(parts) {
+	case 1:
+		// Only the subscription name is present.
+	case 2:
+		info.SensorPath = parts[1]
+	case 3:
+		info.SensorPath = parts[1]
+		info.Producer = parts[2]
+	default:
+		info.SensorPath = parts[2]
+		info.Producer = parts[len(parts)-1]
+	}
+}
+
+// fields gets the non-empty fields of the StreamInfo, keyed by the context key
+// they are applied to devices with.
+func (info *StreamInfo) fields() map[string]string {
+	fields := map[string]string{}
+	for k, v := range map[string]string{
+		"hostname":       info.Hostname,
+		"management_ip":  info.ManagementIP,
+		"routing_engine": info.RoutingEngine,
+		"subscription":   info.Subscription,
+		"sensor_path":    info.SensorPath,
+		"producer":       info.Producer,
+	} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// Context gets the device context for the StreamInfo. Only components which are
+// present are included.
+func (info *StreamInfo) Context() map[string]string {
+	return info.fields()
+}
+
+// Tags gets the device tags for the StreamInfo, in the form "jti/<field>:<value>".
+//
+// Tag labels may not contain colons, slashes, or spaces, so components whose values
+// contain these (e.g. the sensor path or an IPv6 management IP) are not tagged.
+func (info *StreamInfo) Tags() []string {
+	var tags []string
+	for k, v := range info.fields() {
+		if strings.ContainsAny(v, ":/ \t") {
+			continue
+		}
+		tags = append(tags, fmt.Sprintf("jti/%s:%s", k, v))
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Apply the StreamInfo to a DeviceInfo, adding its context to the device context
+// and, if withTags is set, its tags to the device tags. Any context already set on
+// the device takes precedence.
+func (info *StreamInfo) Apply(device *DeviceInfo, withTags bool) {
+	if device.Context == nil {
+		device.Context = map[string]string{}
+	}
+	for k, v := range info.Context() {
+		if _, exists := device.Context[k]; !exists {
+			device.Context[k] = v
+		}
+	}
+	if withTags {
+		device.Tags = append(device.Tags, info.Tags()...)
+	}
+}
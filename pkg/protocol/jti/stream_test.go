@@ -0,0 +1,141 @@
+package jti
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+)
+
+func TestNewStreamInfo(t *testing.T) {
+	systemID := "re0-router1:10.1.1.1"
+	sensorName := "sensor_1000_4_1:/junos/system/linecard/interface/:/junos/system/linecard/interface/:PFE"
+
+	info := NewStreamInfo(&telemetry_top.TelemetryStream{
+		SystemId:   &systemID,
+		SensorName: &sensorName,
+	})
+	assert.Equal(t, &StreamInfo{
+		Hostname:      "router1",
+		ManagementIP:  "10.1.1.1",
+		RoutingEngine: "re0",
+		Subscription:  "sensor_1000_4_1",
+		SensorPath:    "/junos/system/linecard/interface/",
+		Producer:      "PFE",
+	}, info)
+}
+
+func TestStreamInfo_parseSystemID(t *testing.T) {
+	tests := []struct {
+		systemID string
+		expected StreamInfo
+	}{
+		{"", StreamInfo{}},
+		{"router1", StreamInfo{Hostname: "router1"}},
+		{"router1:10.1.1.1", StreamInfo{Hostname: "router1", ManagementIP: "10.1.1.1"}},
+		{"re1-router1:10.1.1.1", StreamInfo{Hostname: "router1", ManagementIP: "10.1.1.1", RoutingEngine: "re1"}},
+		{"router1-re0:10.1.1.1", StreamInfo{Hostname: "router1", ManagementIP: "10.1.1.1", RoutingEngine: "re0"}},
+		{"re0-edge-router-1:fd00::1", StreamInfo{Hostname: "edge-router-1", ManagementIP: "fd00::1", RoutingEngine: "re0"}},
+		{"router1:not-an-ip", StreamInfo{Hostname: "router1"}},
+		{"router-re", StreamInfo{Hostname: "router-re"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.systemID, func(t *testing.T) {
+			info := StreamInfo{}
+			info.parseSystemID(test.systemID)
+			assert.Equal(t, test.expected, info)
+		})
+	}
+}
+
+func TestSystemHostname(t *testing.T) {
+	assert.Equal(t, "router1", SystemHostname("re0-router1:10.1.1.1"))
+	assert.Equal(t, "router1", SystemHostname("router1-re1:10.1.1.2"))
+	assert.Equal(t, "router1", SystemHostname("router1"))
+	assert.Equal(t, "", SystemHostname(""))
+}
+
+func TestStreamInfo_parseSensorName(t *testing.T) {
+	tests := []struct {
+		sensorName string
+		expected   StreamInfo
+	}{
+		{"", StreamInfo{}},
+		{"sub", StreamInfo{Subscription: "sub"}},
+		{"sub:/junos/path/", StreamInfo{Subscription: "sub", SensorPath: "/junos/path/"}},
+		{"sub:/junos/path/:PFE", StreamInfo{Subscription: "sub", SensorPath: "/junos/path/", Producer: "PFE"}},
+		{"sub:/internal/:/junos/path/:PFE", StreamInfo{Subscription: "sub", SensorPath: "/junos/path/", Producer: "PFE"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sensorName, func(t *testing.T) {
+			info := StreamInfo{}
+			info.parseSensorName(test.sensorName)
+			assert.Equal(t, test.expected, info)
+		})
+	}
+}
+
+func TestStreamInfo_Context(t *testing.T) {
+	info := StreamInfo{
+		Hostname:   "router1",
+		SensorPath: "/junos/path/",
+	}
+	assert.Equal(t, map[string]string{
+		"hostname":    "router1",
+		"sensor_path": "/junos/path/",
+	}, info.Context())
+}
+
+func TestStreamInfo_Tags(t *testing.T) {
+	info := StreamInfo{
+		Hostname:      "router1",
+		ManagementIP:  "fd00::1",
+		RoutingEngine: "re0",
+		Subscription:  "sub",
+		SensorPath:    "/junos/path/",
+		Producer:      "PFE",
+	}
+	assert.Equal(t, []string{
+		"jti/hostname:router1",
+		"jti/producer:PFE",
+		"jti/routing_engine:re0",
+		"jti/subscription:sub",
+	}, info.Tags())
+}
+
+func TestStreamInfo_Apply(t *testing.T) {
+	info := StreamInfo{
+		Hostname:      "router1",
+		RoutingEngine: "re0",
+	}
+	device := &DeviceInfo{
+		Tags: []string{"vapor/networking:interface"},
+		Context: map[string]string{
+			"hostname": "existing",
+		},
+	}
+
+	info.Apply(device, false)
+	assert.Equal(t, map[string]string{
+		"hostname":       "existing",
+		"routing_engine": "re0",
+	}, device.Context)
+	assert.Equal(t, []string{"vapor/networking:interface"}, device.Tags)
+
+	info.Apply(device, true)
+	assert.Equal(t, []string{
+		"vapor/networking:interface",
+		"jti/hostname:router1",
+		"jti/routing_engine:re0",
+	}, device.Tags)
+}
+
+func TestStreamInfo_Apply_NilContext(t *testing.T) {
+	info := StreamInfo{Hostname: "router1"}
+	device := &DeviceInfo{}
+
+	info.Apply(device, false)
+	assert.Equal(t, map[string]string{"hostname": "router1"}, device.Context)
+}
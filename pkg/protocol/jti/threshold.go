@@ -0,0 +1,112 @@
+package jti
+
+import (
+	"sync"
+)
+
+// Severity describes the status of a value as evaluated against its thresholds.
+type Severity int
+
+// Severities, in increasing order of severity.
+const (
+	SeverityOk Severity = iota
+	SeverityWarning
+	SeverityAlarm
+)
+
+// String returns the reading value for the Severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityAlarm:
+		return "alarm"
+	default:
+		return "ok"
+	}
+}
+
+// maxSeverity returns the most severe of the given severities.
+func maxSeverity(severities ...Severity) Severity {
+	max := SeverityOk
+	for _, s := range severities {
+		if s > max {
+			max = s
+		}
+	}
+	return max
+}
+
+// Thresholds holds the high and low alarm and warning thresholds for a value. Any
+// threshold which is nil is not reported and is not evaluated.
+type Thresholds struct {
+	HighAlarm   *float64
+	LowAlarm    *float64
+	HighWarning *float64
+	LowWarning  *float64
+}
+
+// classify the value against the thresholds. The margin narrows the band of values
+// which are considered within the thresholds; high thresholds are lowered by the
+// margin and low thresholds are raised by the margin.
+func (t Thresholds) classify(value, margin float64) Severity {
+	if (t.HighAlarm != nil && value >= *t.HighAlarm-margin) || (t.LowAlarm != nil && value <= *t.LowAlarm+margin) {
+		return SeverityAlarm
+	}
+	if (t.HighWarning != nil && value >= *t.HighWarning-margin) || (t.LowWarning != nil && value <= *t.LowWarning+margin) {
+		return SeverityWarning
+	}
+	return SeverityOk
+}
+
+// Evaluate a value against the thresholds, given the severity of the previous
+// evaluation and the hysteresis margin.
+//
+// A value which crosses a threshold raises the severity immediately. To lower
+// the severity, the value needs to return within the threshold by at least the
+// hysteresis margin; otherwise, the previous severity is kept.
+func (t Thresholds) Evaluate(value float64, previous Severity, hysteresis float64) Severity {
+	current := t.classify(value, 0)
+	if current >= previous {
+		return current
+	}
+
+	held := t.classify(value, hysteresis)
+	if held > previous {
+		held = previous
+	}
+	return maxSeverity(current, held)
+}
+
+// thresholdTracker tracks the last evaluated severity for values, so that hysteresis
+// can be applied across samples.
+//
+// A thresholdTracker is safe for concurrent use.
+type thresholdTracker struct {
+	mu         sync.Mutex
+	severities map[string]Severity
+}
+
+// newThresholdTracker creates a new thresholdTracker.
+func newThresholdTracker() *thresholdTracker {
+	return &thresholdTracker{
+		severities: make(map[string]Severity),
+	}
+}
+
+// Evaluate the value against its thresholds using the last known severity for the
+// given key, and record the result as the new severity for the key.
+//
+// A nil tracker evaluates the value as if there were no previous severity.
+func (tracker *thresholdTracker) Evaluate(key string, value float64, t Thresholds, hysteresis float64) Severity {
+	if tracker == nil {
+		return t.Evaluate(value, SeverityOk, hysteresis)
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	severity := t.Evaluate(value, tracker.severities[key], hysteresis)
+	tracker.severities[key] = severity
+	return severity
+}
@@ -0,0 +1,83 @@
+package jti
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+var testThresholds = Thresholds{
+	HighAlarm:   float64Ptr(80),
+	HighWarning: float64Ptr(70),
+	LowWarning:  float64Ptr(0),
+	LowAlarm:    float64Ptr(-10),
+}
+
+func TestSeverity_String(t *testing.T) {
+	assert.Equal(t, "ok", SeverityOk.String())
+	assert.Equal(t, "warning", SeverityWarning.String())
+	assert.Equal(t, "alarm", SeverityAlarm.String())
+}
+
+func Test_maxSeverity(t *testing.T) {
+	assert.Equal(t, SeverityOk, maxSeverity())
+	assert.Equal(t, SeverityWarning, maxSeverity(SeverityOk, SeverityWarning))
+	assert.Equal(t, SeverityAlarm, maxSeverity(SeverityAlarm, SeverityWarning, SeverityOk))
+}
+
+func TestThresholds_Evaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		previous Severity
+		expected Severity
+	}{
+		{"ok", 50, SeverityOk, SeverityOk},
+		{"high warning", 75, SeverityOk, SeverityWarning},
+		{"high alarm", 85, SeverityOk, SeverityAlarm},
+		{"high alarm from warning", 80, SeverityWarning, SeverityAlarm},
+		{"low warning", -5, SeverityOk, SeverityWarning},
+		{"low alarm", -15, SeverityOk, SeverityAlarm},
+		{"alarm held within hysteresis", 79, SeverityAlarm, SeverityAlarm},
+		{"alarm cleared to warning", 77, SeverityAlarm, SeverityWarning},
+		{"alarm cleared to ok", 50, SeverityAlarm, SeverityOk},
+		{"warning held within hysteresis", 69, SeverityWarning, SeverityWarning},
+		{"warning cleared", 67, SeverityWarning, SeverityOk},
+		{"low warning held within hysteresis", 1, SeverityWarning, SeverityWarning},
+		{"low alarm held within hysteresis", -9, SeverityAlarm, SeverityAlarm},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, testThresholds.Evaluate(test.value, test.previous, 2))
+		})
+	}
+}
+
+func TestThresholds_Evaluate_NoThresholds(t *testing.T) {
+	assert.Equal(t, SeverityOk, Thresholds{}.Evaluate(1000, SeverityOk, 2))
+	assert.Equal(t, SeverityOk, Thresholds{}.Evaluate(1000, SeverityAlarm, 2))
+}
+
+func TestThresholdTracker_Evaluate(t *testing.T) {
+	tracker := newThresholdTracker()
+
+	assert.Equal(t, SeverityAlarm, tracker.Evaluate("a", 85, testThresholds, 2))
+	assert.Equal(t, SeverityAlarm, tracker.Evaluate("a", 79, testThresholds, 2))
+	assert.Equal(t, SeverityWarning, tracker.Evaluate("a", 77, testThresholds, 2))
+	assert.Equal(t, SeverityOk, tracker.Evaluate("a", 50, testThresholds, 2))
+
+	// Keys are tracked independently.
+	assert.Equal(t, SeverityWarning, tracker.Evaluate("b", 79, testThresholds, 2))
+}
+
+func TestThresholdTracker_Evaluate_Nil(t *testing.T) {
+	var tracker *thresholdTracker
+
+	assert.Equal(t, SeverityAlarm, tracker.Evaluate("a", 85, testThresholds, 2))
+	assert.Equal(t, SeverityWarning, tracker.Evaluate("a", 79, testThresholds, 2))
+}
@@ -0,0 +1,135 @@
+package protocol
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+// listenQueueSize is the number of samples which may be queued for the device listener.
+// Samples published while the queue is full are dropped.
+const listenQueueSize = 1024
+
+// SamplesKey is the key into the Data field of a server's listener device which stores
+// the channel that the server's samples are published to.
+const SamplesKey = "_device_samples"
+
+// sampleQueue is a queue of samples for the device listener. Samples are only queued
+// once the queue has been subscribed to, so no samples are queued if the plugin does
+// not listen. A sampleQueue is safe for concurrent use.
+type sampleQueue struct {
+	mu     sync.Mutex
+	ch     chan *sdk.ReadContext
+	closed bool
+}
+
+// RegisterListener registers the listener device of the server, which the readings for
+// each device are pushed to Synse from as its data is received, rather than when the
+// device is next read.
+//
+// The SDK only listens for the devices which exist when the plugin starts, but devices
+// are created as their data is received, so this must be called before the plugin starts.
+// The listener device has no readings of its own.
+func (server *JtiUDPServer) RegisterListener() error {
+	server.devicesMu.Lock()
+	defer server.devicesMu.Unlock()
+
+	dev, err := server.newDeviceFromInfo(&jti.DeviceInfo{
+		Type: "listener",
+		Info: fmt.Sprintf("JTI listener %s", server.Address),
+		Tags: []string{
+			"vapor/networking:listener",
+		},
+		Context: map[string]string{},
+		IDComponents: map[string]string{
+			"address": server.Address,
+		},
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	dev.Data[ReadingKey] = []*output.Reading{}
+	dev.Data[SamplesKey] = server.samples.subscribe()
+	if err := server.deviceManager.RegisterDevice(dev); err != nil {
+		log.WithFields(log.Fields{
+			"err":  err,
+			"info": dev.Info,
+		}).Error("[jti] failed to register listener device")
+		return err
+	}
+	return nil
+}
+
+// subscribe to the queue, creating it if it does not yet exist.
+func (q *sampleQueue) subscribe() <-chan *sdk.ReadContext {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.ch == nil {
+		q.ch = make(chan *sdk.ReadContext, listenQueueSize)
+		if q.closed {
+			close(q.ch)
+		}
+	}
+	return q.ch
+}
+
+// publish the readings of a device to the queue, if it has been subscribed to and
+// has not been closed. If the queue is full, the sample is dropped.
+//
+// The caller must hold the devicesMu of the server which owns the device, as the
+// device context is read.
+func (q *sampleQueue) publish(device *sdk.Device, readings []*output.Reading) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ch == nil || q.closed {
+		return
+	}
+
+	select {
+	case q.ch <- sdk.NewReadContext(device, sampleReadings(device, readings)):
+	default:
+		metrics.ListenDropped.Inc()
+	}
+}
+
+// close the queue, so that the listener terminates once it has forwarded the samples
+// which are already queued. Samples published once the queue is closed are discarded.
+func (q *sampleQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed && q.ch != nil {
+		close(q.ch)
+	}
+	q.closed = true
+}
+
+// sampleReadings copies the readings of a device for a sample, applying the device
+// context to each copy.
+//
+// The readings share their context maps with the stored readings of the device, and
+// the device context is updated as devices are linked, so neither can be referenced
+// once the sample is published.
+func sampleReadings(device *sdk.Device, readings []*output.Reading) []*output.Reading {
+	copies := make([]output.Reading, len(readings))
+	result := make([]*output.Reading, len(readings))
+	for i, r := range readings {
+		copies[i] = *r
+		copies[i].Context = make(map[string]string, len(r.Context)+len(device.Context))
+		for k, v := range r.Context {
+			copies[i].Context[k] = v
+		}
+		for k, v := range device.Context {
+			copies[i].Context[k] = v
+		}
+		result[i] = &copies[i]
+	}
+	return result
+}
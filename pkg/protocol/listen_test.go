@@ -0,0 +1,132 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+func TestSampleQueue_publish_NotSubscribed(t *testing.T) {
+	var q sampleQueue
+
+	// Samples are not queued until the queue is subscribed to.
+	q.publish(&sdk.Device{}, []*output.Reading{{Type: "test", Value: 1}})
+	assert.Nil(t, q.ch)
+}
+
+func TestSampleQueue_publish(t *testing.T) {
+	var q sampleQueue
+	ch := q.subscribe()
+	assert.Equal(t, ch, q.subscribe(), "the queue is only created once")
+
+	ctx := map[string]string{"metric": "if_octets"}
+	stored := []*output.Reading{
+		{Type: "test", Value: 1, Context: ctx},
+	}
+	device := &sdk.Device{
+		Context: map[string]string{"site": "test", "metric": "device"},
+	}
+	q.publish(device, stored)
+
+	if assert.Len(t, ch, 1) {
+		sample := <-ch
+		assert.Same(t, device, sample.Device)
+		if assert.Len(t, sample.Reading, 1) {
+			// The device context is applied to a copy of each reading, taking
+			// precedence over the reading context.
+			assert.Equal(t, map[string]string{"site": "test", "metric": "device"}, sample.Reading[0].Context)
+			assert.False(t, stored[0] == sample.Reading[0], "the reading is copied")
+		}
+	}
+	assert.Equal(t, map[string]string{"metric": "if_octets"}, ctx)
+}
+
+func TestSampleQueue_publish_Full(t *testing.T) {
+	var q sampleQueue
+	ch := q.subscribe()
+	dropped := testutil.ToFloat64(metrics.ListenDropped)
+
+	for i := 0; i < listenQueueSize+2; i++ {
+		q.publish(&sdk.Device{}, nil)
+	}
+	assert.Len(t, ch, listenQueueSize)
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.ListenDropped)-dropped)
+}
+
+func TestSampleQueue_close(t *testing.T) {
+	var q sampleQueue
+	ch := q.subscribe()
+	q.publish(&sdk.Device{}, nil)
+	q.close()
+	q.close()
+
+	// Samples which are already queued are still received, and samples published
+	// once the queue is closed are discarded.
+	q.publish(&sdk.Device{}, nil)
+	_, ok := <-ch
+	assert.True(t, ok)
+	_, ok = <-ch
+	assert.False(t, ok)
+
+	// A queue which is subscribed to once closed is already closed.
+	var closed sampleQueue
+	closed.close()
+	_, ok = <-closed.subscribe()
+	assert.False(t, ok)
+}
+
+func TestJtiUDPServer_RegisterListener(t *testing.T) {
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{
+		Address: "udp4://127.0.0.1:0",
+		Context: map[string]string{"site": "test"},
+	}, dm)
+
+	assert.NoError(t, svr.RegisterListener())
+	if assert.Len(t, dm.devices, 1) {
+		for _, device := range dm.devices {
+			assert.Equal(t, "listener", device.Type)
+			assert.Equal(t, "jti", device.Handler)
+			assert.Empty(t, device.Data[ReadingKey])
+			assert.Equal(t, svr.samples.subscribe(), device.Data[SamplesKey])
+		}
+	}
+
+	// The samples channel of the listener device is closed once the server is
+	// shut down.
+	assert.NoError(t, svr.Shutdown(context.Background()))
+	_, ok := <-svr.samples.subscribe()
+	assert.False(t, ok)
+}
+
+func TestJtiUDPServer_RegisterListener_Error(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, manager.NewStubDeviceManager(true))
+	assert.Error(t, svr.RegisterListener())
+}
+
+func TestJtiUDPServer_process_Samples(t *testing.T) {
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, dm)
+	ch := svr.samples.subscribe()
+
+	// Each sample is published as its data is received.
+	svr.process(&packet{data: makeStream(t, "router1", "et-0/0/0")})
+	svr.process(&packet{data: makeStream(t, "router1", "et-0/0/0")})
+
+	device := dm.GetDevice(interfaceDeviceID("router1", "et-0/0/0"))
+	assert.NotNil(t, device)
+	if assert.Len(t, ch, 2) {
+		for i := 0; i < 2; i++ {
+			sample := <-ch
+			assert.Same(t, device, sample.Device)
+			assert.NotEmpty(t, sample.Reading)
+		}
+	}
+}
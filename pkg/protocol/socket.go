@@ -0,0 +1,59 @@
+package protocol
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// batchSize is the maximum number of packets read from a socket in a single batch.
+const batchSize = 32
+
+// batchReader reads a batch of packets from a socket. Where supported (e.g. Linux),
+// this reads multiple packets with a single system call.
+type batchReader interface {
+	ReadBatch(ms []ipv4.Message, flags int) (int, error)
+}
+
+// newBatchReader creates a batchReader for a UDP socket.
+func newBatchReader(conn *net.UDPConn) batchReader {
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() != nil {
+		return ipv4.NewPacketConn(conn)
+	}
+	return ipv6.NewPacketConn(conn)
+}
+
+// parseUDPDrops parses the socket table from /proc/net/udp (or /proc/net/udp6) and
+// gets the total number of packets dropped by the kernel for the sockets with the
+// given inodes.
+//
+// Each line of the table describes a socket, e.g.
+//
+//	sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+//	123: 00000000:1F90 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 34567 2 0000000000000000 12
+func parseUDPDrops(r io.Reader, inodes map[uint64]bool) (uint64, error) {
+	var drops uint64
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 13 {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil || !inodes[inode] {
+			continue
+		}
+		d, err := strconv.ParseUint(fields[12], 10, 64)
+		if err != nil {
+			continue
+		}
+		drops += d
+	}
+	return drops, scanner.Err()
+}
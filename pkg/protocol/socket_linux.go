@@ -0,0 +1,69 @@
+package protocol
+
+import (
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// procNetUDP are the socket tables which report the kernel drops for UDP sockets.
+var procNetUDP = []string{"/proc/net/udp", "/proc/net/udp6"}
+
+// reusePort is a socket control function which enables SO_REUSEPORT on a socket,
+// allowing multiple sockets to bind the same address.
+func reusePort(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// socketInode gets the inode of a socket, which identifies it in the kernel socket tables.
+func socketInode(conn *net.UDPConn) (uint64, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var stat unix.Stat_t
+	var statErr error
+	err = raw.Control(func(fd uintptr) {
+		statErr = unix.Fstat(int(fd), &stat)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if statErr != nil {
+		return 0, statErr
+	}
+	return stat.Ino, nil
+}
+
+// kernelDrops gets the total number of packets dropped by the kernel for the sockets
+// with the given inodes.
+func kernelDrops(inodes map[uint64]bool) (uint64, error) {
+	var drops uint64
+	for _, path := range procNetUDP {
+		f, err := os.Open(path)
+		if err != nil {
+			// The IPv6 table does not exist if IPv6 is disabled.
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, err
+		}
+		d, err := parseUDPDrops(f, inodes)
+		_ = f.Close()
+		if err != nil {
+			return 0, err
+		}
+		drops += d
+	}
+	return drops, nil
+}
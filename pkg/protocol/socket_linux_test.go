@@ -0,0 +1,39 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJtiUDPServer_Connect_ReusePort(t *testing.T) {
+	svr := JtiUDPServer{
+		Address:       "udp4://127.0.0.1:0",
+		Sockets:       3,
+		ReceiveBuffer: 1024 * 1024,
+	}
+	assert.NoError(t, svr.Connect())
+	defer svr.Stop()
+
+	// All of the sockets are bound to the same address.
+	assert.Len(t, svr.conns, 3)
+	addr := svr.conns[0].LocalAddr().String()
+	for _, conn := range svr.conns {
+		assert.Equal(t, addr, conn.LocalAddr().String())
+	}
+}
+
+func Test_kernelDrops(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	inode, err := socketInode(conn)
+	assert.NoError(t, err)
+	assert.NotZero(t, inode)
+
+	drops, err := kernelDrops(map[uint64]bool{inode: true})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), drops)
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+// +build !linux
+
+package protocol
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// errUnsupported is returned for socket features which are not supported on the
+// current platform.
+var errUnsupported = errors.New("not supported on this platform")
+
+// reusePort is a socket control function which enables SO_REUSEPORT on a socket.
+// This is only supported on Linux.
+func reusePort(network, address string, c syscall.RawConn) error {
+	return errUnsupported
+}
+
+// socketInode gets the inode of a socket. This is only supported on Linux.
+func socketInode(conn *net.UDPConn) (uint64, error) {
+	return 0, errUnsupported
+}
+
+// kernelDrops gets the number of packets dropped by the kernel for the sockets with
+// the given inodes. This is only supported on Linux.
+func kernelDrops(inodes map[uint64]bool) (uint64, error) {
+	return 0, errUnsupported
+}
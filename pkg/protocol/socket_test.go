@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/ipv4"
+)
+
+func Test_parseUDPDrops(t *testing.T) {
+	table := `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  123: 00000000:1F90 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 34567 2 0000000000000000 12
+  124: 00000000:1F90 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 34568 2 0000000000000000 3
+  125: 0100007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 11111 2 0000000000000000 100
+  bad line
+`
+
+	drops, err := parseUDPDrops(strings.NewReader(table), map[uint64]bool{34567: true, 34568: true})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(15), drops)
+
+	drops, err = parseUDPDrops(strings.NewReader(table), map[uint64]bool{99999: true})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), drops)
+}
+
+func Test_newBatchReader(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	sender, err := net.DialUDP("udp4", nil, conn.LocalAddr().(*net.UDPAddr))
+	assert.NoError(t, err)
+	defer sender.Close()
+	_, err = sender.Write([]byte("hello"))
+	assert.NoError(t, err)
+
+	msgs := make([]ipv4.Message, 2)
+	for i := range msgs {
+		msgs[i].Buffers = [][]byte{make([]byte, 64)}
+	}
+	n, err := newBatchReader(conn).ReadBatch(msgs, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "hello", string(msgs[0].Buffers[0][:msgs[0].N]))
+	assert.Equal(t, sender.LocalAddr().String(), msgs[0].Addr.String())
+}
@@ -0,0 +1,332 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	cfg "github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+// stateVersion is the version of the state file format. State files of any other
+// version are not restored.
+const stateVersion = 1
+
+// state is the persisted state of the server: the devices it has discovered.
+type state struct {
+	Version int           `json:"version"`
+	Saved   string        `json:"saved"`
+	Devices []deviceState `json:"devices"`
+}
+
+// deviceState is the persisted state of a device. It holds the DeviceInfo the device
+// was built from, along with the source which its data was received from, so that the
+// device can be built again with the same ID and context.
+type deviceState struct {
+	Type     string            `json:"type"`
+	Info     string            `json:"info"`
+	Tags     []string          `json:"tags,omitempty"`
+	Context  map[string]string `json:"context,omitempty"`
+	ID       map[string]string `json:"id"`
+	Source   string            `json:"source,omitempty"`
+	Readings []readingState    `json:"readings,omitempty"`
+}
+
+// readingState is a persisted device reading.
+//
+// The SDK only supports reading values of specific types, so the type of the value is
+// persisted along with it, and the value is restored as that type.
+type readingState struct {
+	Output    string            `json:"output"`
+	Timestamp string            `json:"timestamp"`
+	Kind      string            `json:"kind"`
+	Value     json.RawMessage   `json:"value"`
+	Context   map[string]string `json:"context,omitempty"`
+}
+
+// readingKinds maps the types of reading values which are persisted to a function
+// which allocates a value of that type to restore a value into.
+var readingKinds = map[string]func() interface{}{
+	"string":  func() interface{} { return new(string) },
+	"bool":    func() interface{} { return new(bool) },
+	"float64": func() interface{} { return new(float64) },
+	"float32": func() interface{} { return new(float32) },
+	"int64":   func() interface{} { return new(int64) },
+	"int32":   func() interface{} { return new(int32) },
+	"int":     func() interface{} { return new(int) },
+	"uint64":  func() interface{} { return new(uint64) },
+	"uint32":  func() interface{} { return new(uint32) },
+	"uint":    func() interface{} { return new(uint) },
+}
+
+// registeredDevice is a device which data has been received for, as it is persisted.
+type registeredDevice struct {
+	info   *jti.DeviceInfo
+	source net.IP
+}
+
+// SaveState saves the devices which the server has discovered to the configured state
+// file. If no state file is configured, nothing is saved.
+//
+// The state file is replaced atomically, so a failed save does not corrupt the state
+// which was previously saved.
+func (server *JtiUDPServer) SaveState() error {
+	if server.state.Path == "" {
+		return nil
+	}
+
+	server.stateMu.Lock()
+	defer server.stateMu.Unlock()
+
+	st := server.snapshot()
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(server.state.Path), filepath.Base(server.state.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), server.state.Path); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"path":    server.state.Path,
+		"devices": len(st.Devices),
+	}).Debug("[jti] saved device state")
+	return nil
+}
+
+// snapshot gets the current state of the server's devices, in a stable order.
+func (server *JtiUDPServer) snapshot() *state {
+	server.devicesMu.Lock()
+	defer server.devicesMu.Unlock()
+
+	st := &state{
+		Version: stateVersion,
+		Saved:   time.Now().UTC().Format(time.RFC3339),
+		Devices: make([]deviceState, 0, len(server.registered)),
+	}
+	for id, dev := range server.registered {
+		// The context is copied, as it may be shared with the device, whose
+		// context is updated as devices are linked.
+		ds := deviceState{
+			Type:    dev.info.Type,
+			Info:    dev.info.Info,
+			Tags:    dev.info.Tags,
+			Context: make(map[string]string, len(dev.info.Context)),
+			ID:      dev.info.IDComponents,
+		}
+		for k, v := range dev.info.Context {
+			ds.Context[k] = v
+		}
+		if dev.source != nil {
+			ds.Source = dev.source.String()
+		}
+		if server.state.Readings {
+			if device := server.deviceManager.GetDevice(id); device != nil {
+				readings, _ := device.Data[ReadingKey].([]*output.Reading)
+				ds.Readings = saveReadings(readings)
+			}
+		}
+		st.Devices = append(st.Devices, ds)
+	}
+	sort.Slice(st.Devices, func(i, j int) bool {
+		return st.Devices[i].Info < st.Devices[j].Info
+	})
+	return st
+}
+
+// saveReadings gets the persisted state of device readings. Readings which can not be
+// persisted, e.g. because their value is of an unsupported type, are skipped.
+func saveReadings(readings []*output.Reading) []readingState {
+	states := make([]readingState, 0, len(readings))
+	for _, r := range readings {
+		o := r.GetOutput()
+		if o == nil {
+			continue
+		}
+		kind := fmt.Sprintf("%T", r.Value)
+		if _, ok := readingKinds[kind]; !ok {
+			continue
+		}
+		value, err := json.Marshal(r.Value)
+		if err != nil {
+			// Values such as NaN can not be represented.
+			continue
+		}
+		states = append(states, readingState{
+			Output:    o.Name,
+			Timestamp: r.Timestamp,
+			Kind:      kind,
+			Value:     value,
+			Context:   r.Context,
+		})
+	}
+	return states
+}
+
+// restoreReadings restores persisted device readings. Readings whose output is not
+// registered, or whose value can not be restored, are skipped.
+func restoreReadings(states []readingState) []*output.Reading {
+	readings := make([]*output.Reading, 0, len(states))
+	for _, rs := range states {
+		o := output.Get(rs.Output)
+		newValue, ok := readingKinds[rs.Kind]
+		if o == nil || !ok {
+			continue
+		}
+		value := newValue()
+		if err := json.Unmarshal(rs.Value, value); err != nil {
+			continue
+		}
+		reading := o.MakeReading(reflect.ValueOf(value).Elem().Interface())
+		reading.Timestamp = rs.Timestamp
+		reading.Context = rs.Context
+		readings = append(readings, reading)
+	}
+	return readings
+}
+
+// RestoreState registers the devices from the configured state file, so that the
+// devices which were discovered before the plugin restarted exist before their data is
+// received again. If no state file is configured, or it does not exist, nothing is
+// restored.
+//
+// Devices which are already registered, e.g. because they are expected, are not
+// restored. Restored devices report their persisted readings, if any; otherwise, they
+// report a "no data" status until their data is received.
+func (server *JtiUDPServer) RestoreState() error {
+	if server.state.Path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(server.state.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.WithField("path", server.state.Path).Info("[jti] no device state to restore")
+			return nil
+		}
+		return err
+	}
+
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("failed to parse device state %s: %v", server.state.Path, err)
+	}
+	if st.Version != stateVersion {
+		return fmt.Errorf("unsupported device state version %d in %s", st.Version, server.state.Path)
+	}
+
+	server.devicesMu.Lock()
+	defer server.devicesMu.Unlock()
+
+	var restored int
+	for _, ds := range st.Devices {
+		info := &jti.DeviceInfo{
+			Type:         ds.Type,
+			Info:         ds.Info,
+			Tags:         ds.Tags,
+			Context:      ds.Context,
+			IDComponents: ds.ID,
+		}
+		if info.Context == nil {
+			info.Context = map[string]string{}
+		}
+		source := net.ParseIP(ds.Source)
+
+		if err := server.restoreDevice(info, source, ds.Readings); err != nil {
+			metrics.DeviceErrors.Inc()
+			continue
+		}
+		restored++
+	}
+
+	log.WithFields(log.Fields{
+		"path":     server.state.Path,
+		"saved":    st.Saved,
+		"devices":  len(st.Devices),
+		"restored": restored,
+	}).Info("[jti] restored device state")
+	return nil
+}
+
+// restoreDevice registers a device from its persisted state.
+//
+// The caller must hold devicesMu.
+func (server *JtiUDPServer) restoreDevice(info *jti.DeviceInfo, source net.IP, readings []readingState) error {
+	id, dev, err := server.deviceID(info, source)
+	if err != nil {
+		return err
+	}
+	server.register(id, info, source)
+
+	// The device is already registered.
+	if dev == nil || server.deviceManager.GetDevice(id) != nil {
+		return nil
+	}
+
+	if restored := restoreReadings(readings); len(restored) != 0 {
+		dev.Data[ReadingKey] = restored
+	} else {
+		dev.Data[ReadingKey] = dataStatusReadings(dataStatusNoData)
+	}
+	if err := server.deviceManager.RegisterDevice(dev); err != nil {
+		log.WithFields(log.Fields{
+			"err":  err,
+			"id":   id,
+			"info": dev.Info,
+		}).Error("[jti] failed to register restored device")
+		return err
+	}
+	return nil
+}
+
+// persist saves the state of the server's devices at the configured interval until
+// ctx is done.
+func (server *JtiUDPServer) persist(ctx context.Context) {
+	if server.state.Path == "" {
+		return
+	}
+
+	interval := server.state.Interval
+	if interval <= 0 {
+		interval = cfg.DefaultStateInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := server.SaveState(); err != nil {
+			log.WithError(err).Error("[jti] failed to save device state")
+		}
+	}
+}
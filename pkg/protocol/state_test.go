@@ -0,0 +1,226 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+// tempStatePath creates a temporary directory for a state file, returning the path of the
+// state file and a function which removes the directory.
+func tempStatePath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "jti-state")
+	assert.NoError(t, err)
+	return filepath.Join(dir, "state.json"), func() {
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestJtiUDPServer_SaveState_RestoreState(t *testing.T) {
+	path, cleanup := tempStatePath(t)
+	defer cleanup()
+
+	c := &config.ServerConfig{
+		Address: "localhost",
+		State:   config.StateConfig{Path: path},
+	}
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(c, dm)
+	svr.process(&packet{
+		data:   makeStream(t, "r1:10.0.0.1", "xe-0/0/0", "xe-0/0/1"),
+		source: net.ParseIP("10.0.0.1"),
+	})
+	assert.Len(t, dm.devices, 2)
+	assert.NoError(t, svr.SaveState())
+
+	// Restore the state into a new server, as after a restart.
+	restoredDM := newIDDeviceManager()
+	restored := NewJtiUDPServer(c, restoredDM)
+	assert.NoError(t, restored.RestoreState())
+	assert.Len(t, restoredDM.devices, 2)
+	for id, device := range dm.devices {
+		r, ok := restoredDM.devices[id]
+		if assert.True(t, ok, "device %s was not restored", device.Info) {
+			assert.Equal(t, device.Info, r.Info)
+			assert.Equal(t, device.Type, r.Type)
+			assert.Equal(t, device.Context, r.Context)
+			assert.Equal(t, device.Tags, r.Tags)
+
+			// Readings are not persisted unless enabled.
+			assert.Equal(t, "no data", dataStatus(r))
+		}
+	}
+
+	// The restored devices are persisted again, even if no data is received for them.
+	assert.Len(t, restored.registered, 2)
+	assert.NoError(t, os.Remove(path))
+	assert.NoError(t, restored.SaveState())
+	saved, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	var st state
+	assert.NoError(t, json.Unmarshal(saved, &st))
+	assert.Equal(t, stateVersion, st.Version)
+	assert.Len(t, st.Devices, 2)
+	assert.Equal(t, "r1:10.0.0.1 interface xe-0/0/0", st.Devices[0].Info)
+	assert.Equal(t, "r1:10.0.0.1 interface xe-0/0/1", st.Devices[1].Info)
+	assert.Equal(t, "10.0.0.1", st.Devices[0].Source)
+}
+
+func TestJtiUDPServer_RestoreState_Readings(t *testing.T) {
+	path, cleanup := tempStatePath(t)
+	defer cleanup()
+
+	c := &config.ServerConfig{
+		Address: "localhost",
+		State:   config.StateConfig{Path: path, Readings: true},
+	}
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(c, dm)
+	svr.process(&packet{data: makeStream(t, "r1:10.0.0.1", "xe-0/0/0")})
+	assert.NoError(t, svr.SaveState())
+
+	restoredDM := newIDDeviceManager()
+	assert.NoError(t, NewJtiUDPServer(c, restoredDM).RestoreState())
+	assert.Len(t, restoredDM.devices, 1)
+	for id, device := range restoredDM.devices {
+		readings := device.Data[ReadingKey].([]*output.Reading)
+		assert.NotEmpty(t, readings)
+
+		// Only readings whose outputs are registered can be restored.
+		var expected []*output.Reading
+		for _, r := range dm.devices[id].Data[ReadingKey].([]*output.Reading) {
+			if output.Get(r.GetOutput().Name) != nil {
+				expected = append(expected, r)
+			}
+		}
+		if assert.Len(t, readings, len(expected)) {
+			for i, r := range readings {
+				assert.Equal(t, expected[i].Value, r.Value)
+				assert.Equal(t, expected[i].Context, r.Context)
+				assert.Equal(t, expected[i].Timestamp, r.Timestamp)
+				assert.Equal(t, expected[i].GetOutput(), r.GetOutput())
+			}
+		}
+	}
+}
+
+func TestJtiUDPServer_RestoreState_Expected(t *testing.T) {
+	path, cleanup := tempStatePath(t)
+	defer cleanup()
+
+	c := &config.ServerConfig{
+		Address:  "localhost",
+		State:    config.StateConfig{Path: path, Readings: true},
+		Expected: []config.ExpectedRouter{{SystemID: "r1:10.0.0.1", Interfaces: []config.ExpectedInterface{{Name: "xe-0/0/0"}}}},
+	}
+	svr := NewJtiUDPServer(c, newIDDeviceManager())
+	svr.process(&packet{data: makeStream(t, "r1:10.0.0.1", "xe-0/0/0")})
+	assert.NoError(t, svr.SaveState())
+
+	// Expected devices are registered before the state is restored, and are not
+	// restored over, so they have no data until it is received again.
+	dm := newIDDeviceManager()
+	restored := NewJtiUDPServer(c, dm)
+	assert.NoError(t, restored.RegisterExpected())
+	assert.NoError(t, restored.RestoreState())
+	assert.Len(t, dm.devices, 2)
+	for _, device := range dm.devices {
+		assert.Equal(t, "no data", dataStatus(device))
+	}
+	assert.Len(t, restored.MissingInventory(), 2)
+}
+
+func TestJtiUDPServer_RestoreState_NoState(t *testing.T) {
+	path, cleanup := tempStatePath(t)
+	defer cleanup()
+
+	// No state file is configured.
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, dm)
+	assert.NoError(t, svr.RestoreState())
+	assert.NoError(t, svr.SaveState())
+
+	// The state file does not exist yet.
+	svr = NewJtiUDPServer(&config.ServerConfig{Address: "localhost", State: config.StateConfig{Path: path}}, dm)
+	assert.NoError(t, svr.RestoreState())
+	assert.Empty(t, dm.devices)
+}
+
+func TestJtiUDPServer_RestoreState_Error(t *testing.T) {
+	path, cleanup := tempStatePath(t)
+	defer cleanup()
+
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost", State: config.StateConfig{Path: path}}, newIDDeviceManager())
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{"), 0644))
+	assert.Error(t, svr.RestoreState())
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"version": 99, "devices": []}`), 0644))
+	assert.EqualError(t, svr.RestoreState(), "unsupported device state version 99 in "+path)
+}
+
+func TestJtiUDPServer_Listen_SavesState(t *testing.T) {
+	path, cleanup := tempStatePath(t)
+	defer cleanup()
+
+	svr := NewJtiUDPServer(&config.ServerConfig{
+		Address: "udp4://127.0.0.1:0",
+		State:   config.StateConfig{Path: path},
+	}, newIDDeviceManager())
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- svr.Listen(context.Background())
+	}()
+	waitFor(t, func() bool { return svr.Health() == nil })
+
+	// The state is saved once the listener terminates.
+	assert.NoError(t, svr.Shutdown(context.Background()))
+	assert.NoError(t, <-errs)
+	_, err := os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestSaveReadings_RestoreReadings(t *testing.T) {
+	readings := []*output.Reading{
+		output.Number.MakeReading(uint64(math.MaxUint64)).WithContext(map[string]string{"metric": "big"}),
+		output.Number.MakeReading(uint32(7)),
+		output.Number.MakeReading(-1.5),
+		output.String.MakeReading("up"),
+		output.Status.MakeReading(true),
+
+		// Readings which can not be persisted are skipped.
+		output.Number.MakeReading(math.NaN()),
+		output.Number.MakeReading([]int{1}),
+		{Value: 1},
+	}
+
+	states := saveReadings(readings)
+	assert.Len(t, states, 5)
+
+	restored := restoreReadings(states)
+	if assert.Len(t, restored, 5) {
+		for i, r := range restored {
+			assert.Equal(t, readings[i].Value, r.Value)
+			assert.Equal(t, readings[i].Type, r.Type)
+			assert.Equal(t, readings[i].Context, r.Context)
+			assert.Equal(t, readings[i].Timestamp, r.Timestamp)
+			assert.Equal(t, readings[i].GetOutput(), r.GetOutput())
+		}
+	}
+
+	// Readings whose output or value type are unknown are skipped.
+	states[0].Output = "unknown"
+	states[1].Kind = "complex128"
+	states[2].Value = json.RawMessage(`"x"`)
+	assert.Len(t, restoreReadings(states), 2)
+}
@@ -1,52 +1,183 @@
 package protocol
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"net"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	cfg "github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
 	"github.com/vapor-ware/synse-sdk/sdk"
 	"github.com/vapor-ware/synse-sdk/sdk/config"
+	"golang.org/x/net/ipv4"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	// errStopped is the error returned when connecting a server which has been stopped.
+	errStopped = errors.New("UDP server is stopped")
+
+	// errListening is the error returned when listening with a server which is
+	// already listening.
+	errListening = errors.New("UDP server is already listening")
 )
 
 const (
 	// ReadingKey is the key into a device's Data field which stores the
 	// device reading data.
 	ReadingKey = "_device_readings"
+
+	// rejectLogInterval is the minimum interval between logs for packets which
+	// are rejected because their source is not allowed, or dropped because the
+	// decode queue is full. These packets are counted, but only logged at this
+	// sampled rate to prevent log flooding.
+	rejectLogInterval = 10 * time.Second
+
+	// kernelDropsInterval is the interval at which the number of packets dropped by
+	// the kernel for the server's sockets is checked.
+	kernelDropsInterval = 10 * time.Second
 )
 
 // JtiUDPServer is the UDP server for collecting streamed JTI data over UDP.
+//
+// Packets are read by a reader for each socket and queued for a pool of workers which
+// decode them and update the corresponding devices. Packets are sharded across the
+// workers by the system ID of the device which sent them, so the packets from a single
+// device are always processed in order.
+//
+// The server supervises itself: if a socket fails, the sockets are closed and rebound
+// after an exponential backoff, until the server is stopped. A server can only listen
+// once; after it is stopped, it can not be restarted.
 type JtiUDPServer struct {
 	Address       string
 	BufferSize    uint64
 	GlobalContext map[string]string
+	Workers       int
+	QueueSize     int
+	Sockets       int
+	ReceiveBuffer int
 
-	stopped       bool
-	conn          *net.UDPConn
 	decoder       *jti.JuniperJTIDecoder
 	deviceManager manager.DeviceManager
+	sources       cfg.SourceConfig
+	health        cfg.HealthConfig
+	expected      []cfg.ExpectedRouter
+	state         cfg.StateConfig
+	idComponents  map[string][]string
+
+	// stateMu serializes saves of the state file.
+	stateMu sync.Mutex
+
+	// inventory tracks which devices of the expected inventory have had data
+	// received for them.
+	inventory inventory
+
+	// freshness tracks when valid data was last received, for the data health check.
+	freshness freshness
+
+	// backoff is the delay between attempts to rebind the sockets after they fail.
+	backoff backoff
+
+	// mu guards the state of the listener, which is shared between the listener,
+	// its health check, and Stop.
+	mu        sync.Mutex
+	conns     []*net.UDPConn
+	stopped   bool
+	running   bool
+	listening bool
+	listenErr error
+
+	// cancel cancels the context of the running listen, and done is closed once the
+	// listen has terminated and its workers have processed all queued packets. Both
+	// are nil until the server listens.
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// devicesMu serializes access to the device manager, which is not safe for
+	// concurrent use, across workers. It also guards deviceIDs.
+	devicesMu sync.Mutex
+
+	// deviceIDs caches the ID of each device, keyed by the device's type and ID
+	// components, so that devices which are already known do not need to be built
+	// just to generate their ID.
+	deviceIDs map[string]string
+
+	// registered holds the DeviceInfo of each device which data was received for,
+	// keyed by device ID, so that the devices can be persisted. It is guarded by
+	// devicesMu.
+	registered map[string]registeredDevice
+
+	// samples is the queue which the readings of each device are published to, for
+	// the server's listener device, as its data is received.
+	samples sampleQueue
+
+	// Sampled logging state for rejected and dropped packets. This is shared by
+	// the readers for all sockets.
+	samplingMu    sync.Mutex
+	lastRejectLog time.Time
+	rejectedSince uint64
+	lastDropLog   time.Time
+	droppedSince  uint64
+}
+
+// packet is a received packet which is queued for processing.
+type packet struct {
+	data     []byte
+	source   net.IP
+	systemID string
+	received time.Time
 }
 
 // NewJtiUDPServer creates a new instance of a JtiUDPServer.
-func NewJtiUDPServer(c *cfg.ServerConfig, deviceManager manager.DeviceManager) *JtiUDPServer {
+//
+// Any sensor decoders given are used to decode the sensor extensions which are not
+// supported by the plugin itself.
+func NewJtiUDPServer(c *cfg.ServerConfig, deviceManager manager.DeviceManager, sensors ...jti.SensorDecoder) *JtiUDPServer {
 	return &JtiUDPServer{
 		Address:       c.Address,
 		GlobalContext: c.Context,
 		BufferSize:    64 * 1024, // 64kb, max size of UDP datagram.
-		decoder:       jti.NewJTIDecoder(deviceManager),
+		Workers:       c.Workers,
+		QueueSize:     c.QueueSize,
+		Sockets:       c.Sockets,
+		ReceiveBuffer: c.ReceiveBuffer,
+		decoder:       jti.NewJTIDecoder(c, deviceManager, sensors...),
 		deviceManager: deviceManager,
+		sources:       c.Sources,
+		health:        c.Health,
+		expected:      c.Expected,
+		state:         c.State,
+		idComponents:  c.DeviceIDs.Components,
+		backoff: backoff{
+			min: defaultMinBackoff,
+			max: defaultMaxBackoff,
+		},
 	}
 }
 
-// Connect creates the UDP server connection.
+// Connect creates the UDP server connection(s).
+//
+// If the server is configured with more than one socket, each socket is bound to the
+// same address with SO_REUSEPORT, and the kernel distributes packets across them.
 func (server *JtiUDPServer) Connect() error {
-	if server.conn != nil {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if server.stopped {
+		return errStopped
+	}
+	if len(server.conns) != 0 {
 		log.WithFields(log.Fields{
-			"conn": server.conn,
+			"conns": server.conns,
 		}).Debug("[jti] UDP server already connected")
 		return nil
 	}
@@ -70,98 +201,784 @@ func (server *JtiUDPServer) Connect() error {
 		return err
 	}
 
-	conn, err := net.ListenUDP(network, addr)
-	if err != nil {
-		return err
+	sockets := server.Sockets
+	if sockets <= 0 {
+		sockets = 1
+	}
+	lc := net.ListenConfig{}
+	if sockets > 1 {
+		lc.Control = reusePort
+	}
+
+	var conns []*net.UDPConn
+	for i := 0; i < sockets; i++ {
+		pc, err := lc.ListenPacket(context.Background(), network, addr.String())
+		if err != nil {
+			for _, conn := range conns {
+				_ = conn.Close()
+			}
+			return err
+		}
+		conn := pc.(*net.UDPConn)
+		conns = append(conns, conn)
+
+		// Bind any further sockets to the address the first socket is bound to, in
+		// case the configured port was chosen by the kernel.
+		if i == 0 {
+			addr = conn.LocalAddr().(*net.UDPAddr)
+		}
+
+		if server.ReceiveBuffer > 0 {
+			if err := conn.SetReadBuffer(server.ReceiveBuffer); err != nil {
+				log.WithFields(log.Fields{
+					"err":  err,
+					"size": server.ReceiveBuffer,
+				}).Warning("[jti] failed to set socket receive buffer size")
+			}
+		}
 	}
-	server.conn = conn
+	server.conns = conns
 
 	return nil
 }
 
-// Stop the UDP server from running and close the server connection.
+// Stop the UDP server from running and close the server connection(s).
+//
+// Stop does not wait for the listener to terminate; use Shutdown to wait for the
+// packets which have already been received to be processed.
 func (server *JtiUDPServer) Stop() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
 	server.stopped = true
+	if server.cancel != nil {
+		server.cancel()
+	}
+	server.closeConns()
+}
+
+// Shutdown stops the UDP server and waits for the listener to terminate, after its
+// workers have processed all of the packets which were queued when it was stopped.
+// The queue of samples for the listener device is then closed.
+//
+// If ctx is done before the listener terminates, Shutdown returns the context's
+// error. The server is stopped, and the sample queue closed, regardless.
+func (server *JtiUDPServer) Shutdown(ctx context.Context) error {
+	server.Stop()
+	defer server.samples.close()
+
+	server.mu.Lock()
+	done := server.done
+	server.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeConns closes the server connection(s). The caller must hold mu.
+func (server *JtiUDPServer) closeConns() {
+	for _, conn := range server.conns {
+		_ = conn.Close()
+	}
+	server.conns = nil
+	server.listening = false
+}
+
+// isStopped checks whether the server has been stopped.
+func (server *JtiUDPServer) isStopped() bool {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.stopped
+}
+
+// Health checks the health of the listener. An error is returned if the listener is
+// not running, or if it is not bound to its address, e.g. because it is waiting to
+// rebind after a socket error.
+func (server *JtiUDPServer) Health() error {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	switch {
+	case server.listening:
+		return nil
+	case !server.running:
+		return fmt.Errorf("UDP listener for %s is not running", server.Address)
+	case server.listenErr != nil:
+		return fmt.Errorf("UDP listener is not bound to %s: %v", server.Address, server.listenErr)
+	default:
+		return fmt.Errorf("UDP listener is not bound to %s", server.Address)
+	}
+}
 
-	if server.conn != nil {
-		_ = server.conn.Close()
-		server.conn = nil
+// Freshness checks whether valid data is still being received. An error is returned if
+// no valid packet was received within the configured window, or if system IDs are
+// configured, if any of those systems did not send a valid packet within the window.
+func (server *JtiUDPServer) Freshness() error {
+	window := server.health.Window
+	if window <= 0 {
+		window = cfg.DefaultHealthWindow
 	}
+	return server.freshness.check(window, server.health.SystemIDs, time.Now())
 }
 
 // Listen is the entry point for the server run. It will listen for incoming packets
-// and attempt to decode them into device readings.
+// and queue them for the server's workers, which decode them into device readings.
 //
-// If new devices are found, it will add them to the device manager. All readings are
+// If new devices are found, they are added to the device manager. All readings are
 // associated with a device via the device's Data field.
-func (server *JtiUDPServer) Listen() error {
-	buf := make([]byte, server.BufferSize)
+//
+// Listen runs until ctx is cancelled or the server is stopped, either of which stops
+// the server. Packets which were already queued are processed before it returns. If
+// the sockets fail, or can not be bound, they are rebound after an exponential
+// backoff. The backoff is reset once the sockets have been up for longer than the
+// maximum backoff.
+func (server *JtiUDPServer) Listen(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	server.mu.Lock()
+	if server.stopped {
+		server.mu.Unlock()
+		return nil
+	}
+	if server.done != nil {
+		server.mu.Unlock()
+		return errListening
+	}
+	done := make(chan struct{})
+	server.cancel = cancel
+	server.done = done
+	server.running = true
+	server.mu.Unlock()
+	server.freshness.start(time.Now())
+
+	// The listen is only done once the workers below have finished, so this is
+	// deferred before them. Once the workers have finished, the devices they
+	// updated are saved.
+	defer func() {
+		if err := server.SaveState(); err != nil {
+			log.WithError(err).Error("[jti] failed to save device state")
+		}
+
+		server.mu.Lock()
+		server.running = false
+		server.mu.Unlock()
+		close(done)
+	}()
+
+	// Stop the server once the context is done. This closes the sockets, which
+	// unblocks their readers.
+	go func() {
+		<-ctx.Done()
+		server.Stop()
+	}()
+	go server.persist(ctx)
+
+	workers := server.Workers
+	if workers <= 0 {
+		workers = cfg.DefaultWorkers
+	}
+	queueSize := server.QueueSize
+	if queueSize <= 0 {
+		queueSize = cfg.DefaultQueueSize
+	}
+
+	// Start the workers. They run for as long as the server listens, across any
+	// rebinds of the sockets. Once the listen terminates, the queues are closed and
+	// the workers finish processing any queued packets.
+	queues := make([]chan *packet, workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan *packet, queueSize)
+		wg.Add(1)
+		go func(queue chan *packet) {
+			defer wg.Done()
+			server.work(queue)
+		}(queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
 
+	log.WithFields(log.Fields{
+		"address": server.Address,
+		"buffer":  server.BufferSize,
+		"workers": workers,
+		"queue":   queueSize,
+	}).Info("[jti] starting listener...")
+
+	for {
+		start := time.Now()
+		err := server.serve(queues)
+		if ctx.Err() != nil || server.isStopped() {
+			return nil
+		}
+
+		server.mu.Lock()
+		server.listenErr = err
+		server.mu.Unlock()
+		metrics.ListenerRestarts.Inc()
+
+		if time.Since(start) > server.backoff.max {
+			server.backoff.Reset()
+		}
+		delay := server.backoff.Next()
+		log.WithFields(log.Fields{
+			"err":   err,
+			"retry": delay,
+		}).Error("[jti] UDP listener failed - rebinding after backoff")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+// serve binds the server's sockets and reads packets from them, queueing them for the
+// server's workers, until the server is stopped or a socket fails.
+//
+// If a socket fails, all of the sockets are closed and the error is returned. If the
+// server is stopped, nil is returned.
+func (server *JtiUDPServer) serve(queues []chan *packet) error {
 	if err := server.Connect(); err != nil {
+		if err == errStopped {
+			return nil
+		}
 		log.WithError(err).Error("[jti] error creating UDP connection")
 		return err
 	}
 
+	server.mu.Lock()
+	conns := server.conns
+	server.listening = true
+	server.listenErr = nil
+	server.mu.Unlock()
+
 	log.WithFields(log.Fields{
 		"address": server.Address,
-		"buffer":  server.BufferSize,
+		"sockets": len(conns),
 	}).Info("[jti] listening...")
 
-	for !server.stopped {
-		n, _, err := server.conn.ReadFrom(buf)
+	done := make(chan struct{})
+	defer close(done)
+	go server.pollKernelDrops(conns, done)
+
+	// Start a reader for each socket. If any reader fails, all of the sockets are
+	// closed so the remaining readers terminate as well.
+	readErrs := make(chan error, len(conns))
+	for _, conn := range conns {
+		go func(conn *net.UDPConn) {
+			readErrs <- server.read(conn, queues)
+		}(conn)
+	}
+
+	var serveErr error
+	for range conns {
+		if err := <-readErrs; err != nil && serveErr == nil {
+			serveErr = err
+			server.mu.Lock()
+			server.closeConns()
+			server.mu.Unlock()
+		}
+	}
+	return serveErr
+}
+
+// read packets from a socket in batches, queueing them for the server's workers,
+// until the server is stopped or the read fails.
+func (server *JtiUDPServer) read(conn *net.UDPConn, queues []chan *packet) error {
+	reader := newBatchReader(conn)
+	msgs := make([]ipv4.Message, batchSize)
+	for i := range msgs {
+		msgs[i].Buffers = [][]byte{make([]byte, server.BufferSize)}
+	}
+
+	for !server.isStopped() {
+		n, err := reader.ReadBatch(msgs, 0)
 		if err != nil {
+			if server.isStopped() {
+				return nil
+			}
 			log.WithError(err).Error("[jti] error reading from UDP connection")
 			return err
 		}
 
-		data, err := server.decoder.Decode(buf[:n])
+		received := time.Now()
+		for _, msg := range msgs[:n] {
+			source := sourceIP(msg.Addr)
+			if !server.sources.Allows(source) {
+				server.reject(msg.Addr)
+				continue
+			}
+
+			// The read buffers are reused, so the packet data must be copied before
+			// it is queued.
+			data := make([]byte, msg.N)
+			copy(data, msg.Buffers[0][:msg.N])
+
+			server.enqueue(queues, &packet{
+				data:     data,
+				source:   source,
+				received: received,
+			})
+		}
+	}
+	return nil
+}
+
+// pollKernelDrops periodically updates the kernel drops metric for the given sockets
+// until done is closed.
+func (server *JtiUDPServer) pollKernelDrops(conns []*net.UDPConn, done <-chan struct{}) {
+	inodes := map[uint64]bool{}
+	for _, conn := range conns {
+		inode, err := socketInode(conn)
 		if err != nil {
-			log.WithError(err).Warning("[jti] failed to decode payload into readings - discarding")
+			log.WithError(err).Info("[jti] unable to report kernel drops for UDP socket")
+			return
+		}
+		inodes[inode] = true
+	}
+
+	ticker := time.NewTicker(kernelDropsInterval)
+	defer ticker.Stop()
+
+	var last uint64
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		drops, err := kernelDrops(inodes)
+		if err != nil {
+			log.WithError(err).Debug("[jti] failed to read kernel drops for UDP sockets")
 			continue
 		}
+		metrics.KernelDrops.Set(float64(drops))
+		if drops > last {
+			log.WithFields(log.Fields{
+				"dropped": drops - last,
+				"total":   drops,
+			}).Warning("[jti] kernel dropped packets for UDP socket(s) - consider increasing the receive buffer or number of sockets")
+		}
+		last = drops
+	}
+}
+
+// enqueue a packet on the queue for its worker. If the queue is full, the packet
+// is dropped.
+func (server *JtiUDPServer) enqueue(queues []chan *packet, pkt *packet) {
+	pkt.systemID = peekSystemID(pkt.data)
+	queue := queues[shard(pkt.systemID, len(queues))]
+	select {
+	case queue <- pkt:
+		metrics.QueueDepth.Inc()
+	default:
+		server.drop()
+	}
+}
+
+// work processes the packets from a queue until the queue is closed.
+func (server *JtiUDPServer) work(queue <-chan *packet) {
+	for pkt := range queue {
+		metrics.QueueDepth.Dec()
+		metrics.StageDuration.WithLabelValues("queue").Observe(time.Since(pkt.received).Seconds())
+
+		server.process(pkt)
+	}
+}
+
+// process a received packet, decoding it and updating the devices it has data for.
+//
+// Devices which can not be created or registered are logged and counted, but do not
+// prevent the other devices in the packet from being updated.
+func (server *JtiUDPServer) process(pkt *packet) {
+	start := time.Now()
+	data, err := server.decoder.Decode(pkt.data)
+	metrics.StageDuration.WithLabelValues("decode").Observe(time.Since(start).Seconds())
+	if err != nil {
+		log.WithError(err).Warning("[jti] failed to decode payload into readings - discarding")
+		return
+	}
+
+	// Packets whose data is entirely dropped, e.g. by the filters, do not count as
+	// data received from their system.
+	if len(data) == 0 {
+		return
+	}
+	server.freshness.received(pkt.systemID, time.Now())
+
+	server.devicesMu.Lock()
+	defer server.devicesMu.Unlock()
+
+	start = time.Now()
+	defer func() {
+		metrics.StageDuration.WithLabelValues("devices").Observe(time.Since(start).Seconds())
+	}()
+
+	server.receivedFrom(pkt.systemID)
+
+	for _, d := range data {
+		if err := server.updateDevice(d, pkt.source); err != nil {
+			metrics.DeviceErrors.Inc()
+		}
+	}
+}
 
-		for _, d := range data {
-			dev, err := server.newDeviceFromInfo(d.DeviceInfo)
-			if err != nil {
+// updateDevice updates the device for a decoded data container with its readings,
+// registering the device if it does not yet exist.
+//
+// The caller must hold devicesMu.
+func (server *JtiUDPServer) updateDevice(d *jti.IntermediaryDataContainer, source net.IP) error {
+	links := server.resolveLinks(d.DeviceInfo, source)
+	for k, v := range links {
+		d.DeviceInfo.Context[k] = v
+	}
+
+	deviceID, dev, err := server.deviceID(d.DeviceInfo, source)
+	if err != nil {
+		return err
+	}
+
+	// Attempt to get the device. If the device does not yet exist, register it
+	// with the plugin.
+	device := server.deviceManager.GetDevice(deviceID)
+	if device == nil {
+		log.WithFields(log.Fields{
+			"id": deviceID,
+		}).Info("[jti] device with ID does not exist - creating new device")
+
+		// The ID of the device may already be known without the device having
+		// been registered, e.g. if it was resolved as a link of another device.
+		if dev == nil {
+			if dev, err = server.newDeviceFromInfo(d.DeviceInfo, source); err != nil {
 				return err
 			}
+		}
+		if err := server.deviceManager.RegisterDevice(dev); err != nil {
+			log.WithFields(log.Fields{
+				"err":  err,
+				"id":   deviceID,
+				"info": dev.Info,
+				"ctx":  dev.Context,
+				"type": dev.Type,
+			}).Error("[jti] failed to register new device")
+			return err
+		}
 
-			// Attempt to get the device. If the device does not yet exist, register it
-			// with the plugin.
-			// TODO (etd): The below could be broken out into a helper func (e.g. assignDeviceReadings)
-			//   in order to make this bit easier to test.
-			deviceID := server.deviceManager.GenerateDeviceID(dev)
-			device := server.deviceManager.GetDevice(deviceID)
-			if device == nil {
-				log.WithFields(log.Fields{
-					"id": deviceID,
-				}).Info("[jti] device with ID does not exist - creating new device")
-				if err := server.deviceManager.RegisterDevice(dev); err != nil {
-					log.WithFields(log.Fields{
-						"err":  err,
-						"id":   deviceID,
-						"info": dev.Info,
-						"ctx":  dev.Context,
-						"type": dev.Type,
-					}).Error("[jti] failed to register new device")
-					return err
-				}
-
-				// Since the device is now registered, we can use the Device reference
-				// to add the readings to.
-				device = dev
+		// Since the device is now registered, we can use the Device reference
+		// to add the readings to.
+		device = dev
+	} else {
+		// The linked device may not have been known when the device was
+		// registered, so keep the links on existing devices up to date.
+		//
+		// Devices of the expected inventory are registered before any data is
+		// received for them, so they only get the context from their data once
+		// it is first received.
+		updates := links
+		if server.inventory.received(deviceID) {
+			updates = d.DeviceInfo.Context
+		}
+		device.Context = updateContext(device.Context, updates)
+	}
+
+	// Add the readings to the device data, and push them to the device listener.
+	device.Data[ReadingKey] = d.Readings
+	server.samples.publish(device, d.Readings)
+	server.register(deviceID, d.DeviceInfo, source)
+	return nil
+}
+
+// updateContext gets the context of a registered device with the given updates applied.
+//
+// The SDK reads the context of registered devices while they are updated, so the context
+// is never modified. If any of the updates change it, a new context is returned to
+// replace it; otherwise the context itself is returned.
+func updateContext(current, updates map[string]string) map[string]string {
+	changed := false
+	for k, v := range updates {
+		if existing, ok := current[k]; !ok || existing != v {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return current
+	}
+
+	updated := make(map[string]string, len(current)+len(updates))
+	for k, v := range current {
+		updated[k] = v
+	}
+	for k, v := range updates {
+		updated[k] = v
+	}
+	return updated
+}
+
+// register records the DeviceInfo, and the source, of a device which data was received
+// for, so that the device can be persisted.
+//
+// The caller must hold devicesMu.
+func (server *JtiUDPServer) register(id string, info *jti.DeviceInfo, source net.IP) {
+	if server.registered == nil {
+		server.registered = make(map[string]registeredDevice)
+	}
+	server.registered[id] = registeredDevice{
+		info:   info,
+		source: source,
+	}
+}
+
+// deviceID gets the ID of the device for a DeviceInfo.
+//
+// Device IDs are cached by the device's type and ID components, which are what the
+// ID is generated from. If the ID is not cached, the device is built in order to
+// generate its ID, and the new device is returned as well so that it can be
+// registered. Otherwise, the returned device is nil.
+//
+// The ID components of the DeviceInfo are first narrowed to those configured for its
+// type, so DeviceInfos which only differ by the other components get the same ID.
+//
+// The caller must hold devicesMu.
+func (server *JtiUDPServer) deviceID(info *jti.DeviceInfo, source net.IP) (string, *sdk.Device, error) {
+	server.identify(info)
+	key := deviceKey(info)
+	if id, ok := server.deviceIDs[key]; ok {
+		return id, nil, nil
+	}
+
+	dev, err := server.newDeviceFromInfo(info, source)
+	if err != nil {
+		return "", nil, err
+	}
+	id := server.deviceManager.GenerateDeviceID(dev)
+
+	if server.deviceIDs == nil {
+		server.deviceIDs = make(map[string]string)
+	}
+	server.deviceIDs[key] = id
+	return id, dev, nil
+}
+
+// hostComponent is the ID component which identifies a device by the hostname parsed
+// from its system ID. Unlike the system ID, the hostname does not include the routing
+// engine, so a device has the same hostname regardless of which routing engine streams
+// its data. It is derived from the "sys" component when it is configured.
+const hostComponent = "host"
+
+// identify narrows the ID components of a DeviceInfo to those which are configured to
+// identify devices of its type. Components which are configured, but which the DeviceInfo
+// does not have, are ignored. If no components are configured for the type, the DeviceInfo
+// is not changed.
+func (server *JtiUDPServer) identify(info *jti.DeviceInfo) {
+	keys, ok := server.idComponents[info.Type]
+	if !ok {
+		return
+	}
+
+	// If all of the components are selected, e.g. because the DeviceInfo was already
+	// narrowed, there is nothing to remove.
+	if selectsAll(keys, info.IDComponents) && !derivesHost(keys, info.IDComponents) {
+		return
+	}
+
+	components := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if v, ok := info.IDComponents[k]; ok {
+			components[k] = v
+		} else if sys, ok := info.IDComponents["sys"]; ok && k == hostComponent {
+			components[k] = jti.SystemHostname(sys)
+		}
+	}
+	info.IDComponents = components
+}
+
+// derivesHost checks whether the host component is selected by keys, but has not yet
+// been derived for the given ID components.
+func derivesHost(keys []string, components map[string]string) bool {
+	if _, ok := components[hostComponent]; ok {
+		return false
+	}
+	if _, ok := components["sys"]; !ok {
+		return false
+	}
+	for _, key := range keys {
+		if key == hostComponent {
+			return true
+		}
+	}
+	return false
+}
+
+// selectsAll checks whether all of the given ID components are selected by keys.
+func selectsAll(keys []string, components map[string]string) bool {
+	for k := range components {
+		selected := false
+		for _, key := range keys {
+			if k == key {
+				selected = true
+				break
+			}
+		}
+		if !selected {
+			return false
+		}
+	}
+	return true
+}
+
+// deviceKey gets the key which a device's ID is cached under: the device type and
+// its ID components, in sorted order.
+func deviceKey(info *jti.DeviceInfo) string {
+	keys := make([]string, 0, len(info.IDComponents))
+	size := len(info.Type)
+	for k, v := range info.IDComponents {
+		keys = append(keys, k)
+		size += len(k) + len(v) + 2
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.Grow(size)
+	b.WriteString(info.Type)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(info.IDComponents[k])
+	}
+	return b.String()
+}
+
+// drop records a packet which was dropped because the queue for its worker was full.
+// All dropped packets are counted, but they are only logged at a sampled rate.
+func (server *JtiUDPServer) drop() {
+	metrics.Dropped.Inc()
+
+	server.samplingMu.Lock()
+	defer server.samplingMu.Unlock()
+	server.droppedSince++
+
+	now := time.Now()
+	if now.Sub(server.lastDropLog) < rejectLogInterval {
+		return
+	}
+	log.WithFields(log.Fields{
+		"dropped": server.droppedSince,
+	}).Warning("[jti] dropped packet(s) because the decode queue is full")
+	server.lastDropLog = now
+	server.droppedSince = 0
+}
+
+// peekSystemID gets the system ID from an encoded TelemetryStream message without
+// decoding the whole message. If the system ID can not be found, an empty string is
+// returned.
+func peekSystemID(b []byte) string {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return ""
+		}
+		b = b[n:]
+
+		if num == 1 && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return ""
 			}
+			return string(v)
+		}
 
-			// Add the readings to the device data.
-			device.Data[ReadingKey] = d.Readings
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return ""
 		}
+		b = b[n:]
+	}
+	return ""
+}
+
+// shard gets the index of the worker which processes the packets for a system ID.
+func shard(systemID string, workers int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(systemID))
+	return int(h.Sum32() % uint32(workers))
+}
+
+// resolveLinks resolves the devices which a DeviceInfo is linked to into their device IDs,
+// keyed by the context key the ID should be stored under. Links which can not be resolved
+// are skipped.
+func (server *JtiUDPServer) resolveLinks(info *jti.DeviceInfo, source net.IP) map[string]string {
+	if len(info.Links) == 0 {
+		return nil
 	}
 
+	links := make(map[string]string, len(info.Links))
+	for key, link := range info.Links {
+		id, _, err := server.deviceID(link, source)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"err":  err,
+				"link": key,
+				"info": info.Info,
+			}).Warning("[jti] failed to resolve linked device")
+			continue
+		}
+		links[key] = id
+	}
+	return links
+}
+
+// reject records a packet which was rejected because its source is not allowed. All
+// rejected packets are counted, but they are only logged at a sampled rate.
+func (server *JtiUDPServer) reject(addr net.Addr) {
+	metrics.Rejected.Inc()
+
+	server.samplingMu.Lock()
+	defer server.samplingMu.Unlock()
+	server.rejectedSince++
+
+	now := time.Now()
+	if now.Sub(server.lastRejectLog) < rejectLogInterval {
+		return
+	}
+	log.WithFields(log.Fields{
+		"source":   addr,
+		"rejected": server.rejectedSince,
+	}).Warning("[jti] rejected packet(s) from source which is not allowed")
+	server.lastRejectLog = now
+	server.rejectedSince = 0
+}
+
+// sourceIP gets the IP address of the source of a packet. If the address does not
+// have an IP, nil is returned.
+func sourceIP(addr net.Addr) net.IP {
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		return udpAddr.IP
+	}
 	return nil
 }
 
 // newDeviceFromInfo is a utility function which creates a new SDK Device given a DeviceInfo
-// constructed while parsing data from an incoming JTI stream.
+// constructed while parsing data from an incoming JTI stream, and the IP address of the
+// source which sent the data (which may be nil if it is not known).
 //
 // It is important to note that the global context configured for the UDP server is applied
 // to the device at this level. The global context is defined at the prototype level, whereas
@@ -169,14 +986,29 @@ func (server *JtiUDPServer) Listen() error {
 // is that when the SDK builds the device and merges the context, if the device info (instance
 // level) has keys which conflict with the global context (prototype level), the global level
 // will be overwritten.
-func (server *JtiUDPServer) newDeviceFromInfo(info *jti.DeviceInfo) (*sdk.Device, error) {
+//
+// Any context configured for the source is merged into the global context at the prototype
+// level, taking precedence over the global context.
+func (server *JtiUDPServer) newDeviceFromInfo(info *jti.DeviceInfo, source net.IP) (*sdk.Device, error) {
+	protoContext := server.GlobalContext
+	if sourceCtx := server.sources.ContextFor(source, info.Context["system_id"]); sourceCtx != nil {
+		protoContext = make(map[string]string, len(server.GlobalContext)+len(sourceCtx))
+		for k, v := range server.GlobalContext {
+			protoContext[k] = v
+		}
+		for k, v := range sourceCtx {
+			protoContext[k] = v
+		}
+	}
+
 	dev, err := server.deviceManager.NewDevice(
 		&config.DeviceProto{
 			Type:    info.Type,
-			Context: server.GlobalContext,
+			Context: protoContext,
 			Tags:    info.Tags,
 			Data: map[string]interface{}{
-				"id": info.IDComponents,
+				"id":   info.IDComponents,
+				"type": info.Type,
 			},
 			Handler: "jti",
 			// Writes are not supported, but this timeout is set to silence semi-verbose SDK
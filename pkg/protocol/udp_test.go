@@ -1,13 +1,23 @@
 package protocol
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/config"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/manager"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/metrics"
 	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/port"
+	"github.com/vapor-ware/synse-juniper-jti-plugin/pkg/protocol/jti/protos/telemetry_top"
+	"github.com/vapor-ware/synse-sdk/sdk"
 )
 
 func TestNewJtiUDPServer(t *testing.T) {
@@ -25,15 +35,38 @@ func TestNewJtiUDPServer(t *testing.T) {
 	assert.Equal(t, map[string]string{"site": "test"}, svr.GlobalContext)
 	assert.Equal(t, svr.BufferSize, uint64(64*1024))
 	assert.False(t, svr.stopped)
-	assert.Nil(t, svr.conn)
+	assert.Nil(t, svr.conns)
 	assert.NotNil(t, svr.decoder)
 	assert.NotNil(t, svr.deviceManager)
 }
 
+// makeStream creates the encoded bytes for a TelemetryStream message with port data
+// for the given interfaces.
+func makeStream(t assert.TestingT, systemID string, names ...string) []byte {
+	p := &port.Port{}
+	for i := range names {
+		p.InterfaceStats = append(p.InterfaceStats, &port.InterfaceInfos{
+			IfName: &names[i],
+		})
+	}
+
+	jns := &telemetry_top.JuniperNetworksSensors{}
+	assert.NoError(t, proto.SetExtension(jns, port.E_JnprInterfaceExt, p))
+	enterprise := &telemetry_top.EnterpriseSensors{}
+	assert.NoError(t, proto.SetExtension(enterprise, telemetry_top.E_JuniperNetworks, jns))
+
+	b, err := proto.Marshal(&telemetry_top.TelemetryStream{
+		SystemId:   &systemID,
+		Enterprise: enterprise,
+	})
+	assert.NoError(t, err)
+	return b
+}
+
 func TestJtiUDPServer_Stop_nilConn(t *testing.T) {
 	svr := JtiUDPServer{}
 	assert.False(t, svr.stopped)
-	assert.Nil(t, svr.conn)
+	assert.Nil(t, svr.conns)
 
 	svr.Stop()
 	assert.True(t, svr.stopped)
@@ -41,13 +74,194 @@ func TestJtiUDPServer_Stop_nilConn(t *testing.T) {
 
 func TestJtiUDPServer_Stop(t *testing.T) {
 	svr := JtiUDPServer{
-		conn: &net.UDPConn{},
+		conns: []*net.UDPConn{{}},
 	}
 	assert.False(t, svr.stopped)
-	assert.NotNil(t, svr.conn)
+	assert.NotNil(t, svr.conns)
 
 	svr.Stop()
 	assert.True(t, svr.stopped)
+	assert.Nil(t, svr.conns)
+}
+
+func TestJtiUDPServer_Connect_Stopped(t *testing.T) {
+	svr := JtiUDPServer{Address: "udp4://127.0.0.1:0"}
+	svr.Stop()
+
+	assert.Equal(t, errStopped, svr.Connect())
+	assert.Nil(t, svr.conns)
+}
+
+func TestJtiUDPServer_Health(t *testing.T) {
+	svr := JtiUDPServer{Address: "localhost:5000"}
+	assert.EqualError(t, svr.Health(), "UDP listener for localhost:5000 is not running")
+
+	svr.running = true
+	assert.EqualError(t, svr.Health(), "UDP listener is not bound to localhost:5000")
+
+	svr.listening = true
+	assert.NoError(t, svr.Health())
+
+	svr.listening = false
+	svr.listenErr = errors.New("address already in use")
+	assert.EqualError(t, svr.Health(), "UDP listener is not bound to localhost:5000: address already in use")
+}
+
+// waitFor waits for a condition to be met, failing the test if it is not met in time.
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJtiUDPServer_Listen_Rebind(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "udp4://127.0.0.1:0"}, manager.NewStubDeviceManager(false))
+	svr.backoff = backoff{min: 10 * time.Millisecond, max: 100 * time.Millisecond}
+	restarts := testutil.ToFloat64(metrics.ListenerRestarts)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- svr.Listen(context.Background())
+	}()
+	waitFor(t, func() bool { return svr.Health() == nil })
+
+	// Simulate a socket error by closing the socket out from under the reader. The
+	// listener is unhealthy until the socket is rebound.
+	svr.mu.Lock()
+	_ = svr.conns[0].Close()
+	svr.mu.Unlock()
+	waitFor(t, func() bool { return testutil.ToFloat64(metrics.ListenerRestarts)-restarts == 1 })
+	waitFor(t, func() bool { return svr.Health() == nil })
+
+	svr.Stop()
+	assert.NoError(t, <-errs)
+	assert.Error(t, svr.Health())
+}
+
+func TestJtiUDPServer_Freshness(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{
+		Address: "udp4://127.0.0.1:0",
+		Health:  config.HealthConfig{Window: time.Minute},
+	}, manager.NewStubDeviceManager(false))
+	assert.EqualError(t, svr.Freshness(), "no valid JTI data received: the listener has not started")
+
+	// Data is fresh until the window since the listener started has elapsed.
+	svr.freshness.start(time.Now())
+	assert.NoError(t, svr.Freshness())
+	svr.freshness.start(time.Now().Add(-2 * time.Minute))
+	assert.EqualError(t, svr.Freshness(), "no valid JTI data received within 1m0s")
+
+	// Invalid packets do not count as received data.
+	svr.process(&packet{data: []byte{0xff}, systemID: "test-system"})
+	assert.Error(t, svr.Freshness())
+
+	svr.process(&packet{data: makeStream(t, "test-system", "xe-0/0/0"), systemID: "test-system"})
+	assert.NoError(t, svr.Freshness())
+}
+
+func TestJtiUDPServer_Listen_Stopped(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "udp4://127.0.0.1:0"}, manager.NewStubDeviceManager(false))
+	svr.Stop()
+
+	assert.NoError(t, svr.Listen(context.Background()))
+	assert.Nil(t, svr.conns)
+	assert.NoError(t, svr.Shutdown(context.Background()))
+}
+
+func TestJtiUDPServer_Listen_Twice(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "udp4://127.0.0.1:0"}, manager.NewStubDeviceManager(false))
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- svr.Listen(context.Background())
+	}()
+	waitFor(t, func() bool { return svr.Health() == nil })
+
+	assert.Equal(t, errListening, svr.Listen(context.Background()))
+
+	assert.NoError(t, svr.Shutdown(context.Background()))
+	assert.NoError(t, <-errs)
+}
+
+func TestJtiUDPServer_Listen_ContextCancelled(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "udp4://127.0.0.1:0"}, manager.NewStubDeviceManager(false))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- svr.Listen(ctx)
+	}()
+	waitFor(t, func() bool { return svr.Health() == nil })
+
+	cancel()
+	assert.NoError(t, <-errs)
+	assert.True(t, svr.isStopped())
+	assert.Error(t, svr.Health())
+	assert.Equal(t, errStopped, svr.Connect())
+}
+
+// blockingDeviceManager is a DeviceManager which blocks when getting a device until
+// it is released, so that tests can hold a worker in the middle of processing.
+type blockingDeviceManager struct {
+	manager.DeviceManager
+
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (dm *blockingDeviceManager) GetDevice(id string) *sdk.Device {
+	select {
+	case dm.entered <- struct{}{}:
+	default:
+	}
+	<-dm.release
+	return dm.DeviceManager.GetDevice(id)
+}
+
+func TestJtiUDPServer_Shutdown(t *testing.T) {
+	dm := &blockingDeviceManager{
+		DeviceManager: newIDDeviceManager(),
+		entered:       make(chan struct{}, 1),
+		release:       make(chan struct{}),
+	}
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "udp4://127.0.0.1:0", Workers: 1}, dm)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- svr.Listen(context.Background())
+	}()
+	waitFor(t, func() bool { return svr.Health() == nil })
+
+	svr.mu.Lock()
+	addr := svr.conns[0].LocalAddr().(*net.UDPAddr)
+	svr.mu.Unlock()
+	conn, err := net.DialUDP("udp4", nil, addr)
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write(makeStream(t, "test-system", "xe-0/0/0"))
+	assert.NoError(t, err)
+
+	// Wait for the worker to start processing the packet. While it is processing,
+	// the shutdown can not complete.
+	<-dm.entered
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, svr.Shutdown(ctx))
+	select {
+	case err := <-errs:
+		t.Fatalf("listen terminated before processing completed: %v", err)
+	default:
+	}
+
+	// Once the packet has been processed, the shutdown completes.
+	close(dm.release)
+	assert.NoError(t, svr.Shutdown(context.Background()))
+	assert.NoError(t, <-errs)
+	assert.Len(t, dm.DeviceManager.(*idDeviceManager).devices, 1)
 }
 
 func TestNewDeviceFromInfo(t *testing.T) {
@@ -72,7 +286,7 @@ func TestNewDeviceFromInfo(t *testing.T) {
 		deviceManager: manager.NewStubDeviceManager(false),
 	}
 
-	dev, err := svr.newDeviceFromInfo(&info)
+	dev, err := svr.newDeviceFromInfo(&info, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, "device-type", dev.Type)
 	assert.Equal(t, "device-info", dev.Info)
@@ -84,6 +298,7 @@ func TestNewDeviceFromInfo(t *testing.T) {
 		"id": map[string]string{
 			"foo": "bar",
 		},
+		"type": "device-type",
 	}, dev.Data)
 	assert.Len(t, dev.Tags, 1)
 	tag := dev.Tags[0]
@@ -116,7 +331,7 @@ func TestNewDeviceFromInfo2(t *testing.T) {
 		deviceManager: manager.NewStubDeviceManager(false),
 	}
 
-	dev, err := svr.newDeviceFromInfo(&info)
+	dev, err := svr.newDeviceFromInfo(&info, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, "device-type", dev.Type)
 	assert.Equal(t, "device-info", dev.Info)
@@ -129,6 +344,7 @@ func TestNewDeviceFromInfo2(t *testing.T) {
 		"id": map[string]string{
 			"foo": "bar",
 		},
+		"type": "device-type",
 	}, dev.Data)
 	assert.Len(t, dev.Tags, 1)
 	tag := dev.Tags[0]
@@ -163,7 +379,7 @@ func TestNewDeviceFromInfo3(t *testing.T) {
 		deviceManager: manager.NewStubDeviceManager(false),
 	}
 
-	dev, err := svr.newDeviceFromInfo(&info)
+	dev, err := svr.newDeviceFromInfo(&info, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, "device-type", dev.Type)
 	assert.Equal(t, "device-info", dev.Info)
@@ -177,6 +393,7 @@ func TestNewDeviceFromInfo3(t *testing.T) {
 		"id": map[string]string{
 			"foo": "bar",
 		},
+		"type": "device-type",
 	}, dev.Data)
 	assert.Len(t, dev.Tags, 1)
 	tag := dev.Tags[0]
@@ -205,7 +422,365 @@ func TestNewDeviceFromInfo_Error(t *testing.T) {
 		deviceManager: manager.NewStubDeviceManager(true),
 	}
 
-	dev, err := svr.newDeviceFromInfo(&info)
+	dev, err := svr.newDeviceFromInfo(&info, nil)
 	assert.Error(t, err)
 	assert.Nil(t, dev)
 }
+
+func TestNewDeviceFromInfo_SourceContext(t *testing.T) {
+	info := jti.DeviceInfo{
+		Type: "device-type",
+		Info: "device-info",
+		Context: map[string]string{
+			"system_id": "router-1",
+			"common":    "device-value",
+		},
+		IDComponents: map[string]string{
+			"foo": "bar",
+		},
+	}
+
+	sources := config.SourceConfig{
+		Context: []config.SourceContext{
+			{CIDR: "10.1.0.0/16", Context: map[string]string{"site": "site-1", "global-ctx": "source"}},
+			{SystemID: "router-1", Context: map[string]string{"rack": "r1", "common": "source-value"}},
+		},
+	}
+	assert.NoError(t, sources.Compile())
+
+	svr := JtiUDPServer{
+		GlobalContext: map[string]string{
+			"global-ctx": "123",
+		},
+		deviceManager: manager.NewStubDeviceManager(false),
+		sources:       sources,
+	}
+
+	dev, err := svr.newDeviceFromInfo(&info, net.ParseIP("10.1.1.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"system_id":  "router-1",
+		"common":     "device-value",
+		"global-ctx": "source",
+		"site":       "site-1",
+		"rack":       "r1",
+	}, dev.Context)
+
+	// The global context is not modified.
+	assert.Equal(t, map[string]string{"global-ctx": "123"}, svr.GlobalContext)
+}
+
+func TestJtiUDPServer_resolveLinks(t *testing.T) {
+	svr := JtiUDPServer{
+		GlobalContext: map[string]string{},
+		deviceManager: manager.NewStubDeviceManager(false),
+	}
+
+	links := svr.resolveLinks(&jti.DeviceInfo{
+		Type: "optic",
+		Links: map[string]*jti.DeviceInfo{
+			"interface_device_id": {
+				Type:         "interface",
+				IDComponents: map[string]string{"sys": "test", "if": "et-0/0/0"},
+			},
+		},
+	}, nil)
+	assert.Equal(t, map[string]string{"interface_device_id": "test-device-id"}, links)
+}
+
+func TestJtiUDPServer_updateDevice_Context(t *testing.T) {
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, dm)
+	svr.devicesMu.Lock()
+	defer svr.devicesMu.Unlock()
+
+	optic := func() *jti.IntermediaryDataContainer {
+		return &jti.IntermediaryDataContainer{
+			DeviceInfo: &jti.DeviceInfo{
+				Type:         "optic",
+				Context:      map[string]string{"system_id": "router1"},
+				IDComponents: map[string]string{"sys": "router1", "if": "et-0/0/0"},
+				Links: map[string]*jti.DeviceInfo{
+					"interface_device_id": {
+						Type:         "interface",
+						IDComponents: map[string]string{"sys": "router1", "if": "et-0/0/0"},
+					},
+				},
+			},
+		}
+	}
+	assert.NoError(t, svr.updateDevice(optic(), nil))
+	if !assert.Len(t, dm.devices, 1) {
+		return
+	}
+	var device *sdk.Device
+	for _, d := range dm.devices {
+		device = d
+	}
+	registered := device.Context
+	assert.Equal(t, "router1", registered["system_id"])
+	assert.NotEmpty(t, registered["interface_device_id"])
+
+	// If the data does not change the context, the context is kept.
+	assert.NoError(t, svr.updateDevice(optic(), nil))
+	assert.Equal(t, fmt.Sprintf("%p", registered), fmt.Sprintf("%p", device.Context))
+
+	// The context of a registered device is read by the SDK while it is updated, so
+	// it is replaced rather than modified.
+	data := optic()
+	data.DeviceInfo.Links["interface_device_id"].IDComponents["if"] = "et-0/0/1"
+	assert.NoError(t, svr.updateDevice(data, nil))
+	assert.NotEqual(t, registered["interface_device_id"], device.Context["interface_device_id"])
+	assert.NotEqual(t, fmt.Sprintf("%p", registered), fmt.Sprintf("%p", device.Context))
+	assert.Equal(t, "router1", device.Context["system_id"])
+}
+
+func TestJtiUDPServer_resolveLinks_NoLinks(t *testing.T) {
+	svr := JtiUDPServer{}
+	assert.Nil(t, svr.resolveLinks(&jti.DeviceInfo{Type: "optic"}, nil))
+}
+
+func TestJtiUDPServer_resolveLinks_Error(t *testing.T) {
+	svr := JtiUDPServer{
+		GlobalContext: map[string]string{},
+		deviceManager: manager.NewStubDeviceManager(true),
+	}
+
+	links := svr.resolveLinks(&jti.DeviceInfo{
+		Type: "optic",
+		Links: map[string]*jti.DeviceInfo{
+			"interface_device_id": {Type: "interface"},
+		},
+	}, nil)
+	assert.Empty(t, links)
+}
+
+func TestJtiUDPServer_deviceID(t *testing.T) {
+	svr := JtiUDPServer{
+		GlobalContext: map[string]string{},
+		deviceManager: manager.NewStubDeviceManager(false),
+	}
+	info := &jti.DeviceInfo{
+		Type:         "interface",
+		IDComponents: map[string]string{"sys": "test", "if": "et-0/0/0"},
+	}
+
+	// The device is built to generate its ID the first time.
+	id, dev, err := svr.deviceID(info, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-device-id", id)
+	assert.NotNil(t, dev)
+
+	// The ID is cached after that, so the device is not built again.
+	id, dev, err = svr.deviceID(info, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-device-id", id)
+	assert.Nil(t, dev)
+}
+
+func TestJtiUDPServer_deviceID_Error(t *testing.T) {
+	svr := JtiUDPServer{
+		GlobalContext: map[string]string{},
+		deviceManager: manager.NewStubDeviceManager(true),
+	}
+
+	id, dev, err := svr.deviceID(&jti.DeviceInfo{Type: "interface"}, nil)
+	assert.Error(t, err)
+	assert.Empty(t, id)
+	assert.Nil(t, dev)
+	assert.Empty(t, svr.deviceIDs)
+}
+
+func Test_deviceKey(t *testing.T) {
+	info := &jti.DeviceInfo{
+		Type:         "interface",
+		IDComponents: map[string]string{"sys": "test", "if": "et-0/0/0", "cid": "0"},
+	}
+	assert.Equal(t, "interface\x00cid=0\x00if=et-0/0/0\x00sys=test", deviceKey(info))
+
+	// Devices of different types with the same ID components have different keys.
+	assert.NotEqual(t, deviceKey(info), deviceKey(&jti.DeviceInfo{
+		Type:         "optic",
+		IDComponents: info.IDComponents,
+	}))
+	assert.Equal(t, "interface", deviceKey(&jti.DeviceInfo{Type: "interface"}))
+}
+
+func TestJtiUDPServer_reject(t *testing.T) {
+	svr := JtiUDPServer{}
+	addr := &net.UDPAddr{IP: net.ParseIP("10.1.1.1"), Port: 5000}
+
+	before := testutil.ToFloat64(metrics.Rejected)
+	svr.reject(addr)
+	assert.Equal(t, uint64(0), svr.rejectedSince)
+	assert.False(t, svr.lastRejectLog.IsZero())
+
+	// Subsequent rejects within the log interval are counted but not logged.
+	svr.reject(addr)
+	svr.reject(addr)
+	assert.Equal(t, uint64(2), svr.rejectedSince)
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.Rejected)-before)
+}
+
+func Test_sourceIP(t *testing.T) {
+	assert.Equal(t, net.ParseIP("10.1.1.1"), sourceIP(&net.UDPAddr{IP: net.ParseIP("10.1.1.1")}))
+	assert.Nil(t, sourceIP(&net.TCPAddr{IP: net.ParseIP("10.1.1.1")}))
+	assert.Nil(t, sourceIP(nil))
+}
+
+func TestJtiUDPServer_process(t *testing.T) {
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, dm)
+
+	svr.process(&packet{data: makeStream(t, "router1", "et-0/0/0")})
+
+	device := dm.GetDevice(interfaceDeviceID("router1", "et-0/0/0"))
+	assert.NotNil(t, device)
+	assert.NotEmpty(t, device.Data[ReadingKey])
+
+	// The readings of the known device are replaced by those of later packets.
+	readings := device.Data[ReadingKey]
+	svr.process(&packet{data: makeStream(t, "router1", "et-0/0/0")})
+	assert.Same(t, device, dm.GetDevice(interfaceDeviceID("router1", "et-0/0/0")))
+	assert.Len(t, dm.devices, 1)
+	assert.NotEqual(t, fmt.Sprintf("%p", readings), fmt.Sprintf("%p", device.Data[ReadingKey]))
+}
+
+func TestJtiUDPServer_process_DecodeError(t *testing.T) {
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, dm)
+
+	// Packets which fail to decode are discarded.
+	svr.process(&packet{data: []byte{0xff}})
+	assert.Empty(t, dm.devices)
+}
+
+func TestJtiUDPServer_process_DeviceError(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, manager.NewStubDeviceManager(true))
+	errs := testutil.ToFloat64(metrics.DeviceErrors)
+
+	// Each device which fails is counted, and does not prevent the other devices
+	// from being processed.
+	svr.process(&packet{data: makeStream(t, "router1", "et-0/0/0", "et-0/0/1")})
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.DeviceErrors)-errs)
+}
+
+func TestJtiUDPServer_work(t *testing.T) {
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, manager.NewStubDeviceManager(true))
+	errs := testutil.ToFloat64(metrics.DeviceErrors)
+
+	queue := make(chan *packet, 2)
+	queue <- &packet{data: makeStream(t, "router1", "et-0/0/0"), received: time.Now()}
+	queue <- &packet{data: makeStream(t, "router1", "et-0/0/1"), received: time.Now()}
+	close(queue)
+	metrics.QueueDepth.Add(2)
+
+	// Packets continue to be processed after a device fails.
+	svr.work(queue)
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.DeviceErrors)-errs)
+}
+
+func TestJtiUDPServer_enqueue(t *testing.T) {
+	svr := JtiUDPServer{}
+	queues := []chan *packet{make(chan *packet, 1), make(chan *packet, 1)}
+	data := makeStream(t, "router1", "et-0/0/0")
+	queue := queues[shard("router1", 2)]
+
+	depth := testutil.ToFloat64(metrics.QueueDepth)
+	dropped := testutil.ToFloat64(metrics.Dropped)
+
+	svr.enqueue(queues, &packet{data: data})
+	assert.Len(t, queue, 1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.QueueDepth)-depth)
+
+	// The queue for the system ID is full, so the packet is dropped.
+	svr.enqueue(queues, &packet{data: data})
+	assert.Len(t, queue, 1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Dropped)-dropped)
+	assert.Equal(t, uint64(0), svr.droppedSince)
+	assert.False(t, svr.lastDropLog.IsZero())
+
+	<-queue
+	metrics.QueueDepth.Dec()
+}
+
+func Test_peekSystemID(t *testing.T) {
+	assert.Equal(t, "router1:10.1.1.1", peekSystemID(makeStream(t, "router1:10.1.1.1", "et-0/0/0")))
+	assert.Equal(t, "", peekSystemID(nil))
+	assert.Equal(t, "", peekSystemID([]byte{0xff}))
+}
+
+func Test_shard(t *testing.T) {
+	for _, id := range []string{"", "router1", "router2", "re0-router3:10.1.1.1"} {
+		s := shard(id, 4)
+		assert.True(t, s >= 0 && s < 4)
+		assert.Equal(t, s, shard(id, 4))
+	}
+	assert.Equal(t, 0, shard("router1", 1))
+}
+
+// makeBenchmarkStream creates the encoded bytes for a TelemetryStream message with port
+// data for the given number of interfaces, each with traffic statistics and eight egress
+// queues, similar to the data streamed by a large router.
+func makeBenchmarkStream(b *testing.B, systemID string, interfaces int) []byte {
+	p := &port.Port{}
+	for i := 0; i < interfaces; i++ {
+		stats := func() *port.InterfaceStats {
+			return &port.InterfaceStats{
+				IfPkts:        proto.Uint64(uint64(1000000 + i)),
+				IfOctets:      proto.Uint64(uint64(900000000 + i)),
+				If_1SecPkts:   proto.Uint64(uint64(10000 + i)),
+				If_1SecOctets: proto.Uint64(uint64(9000000 + i)),
+			}
+		}
+
+		info := &port.InterfaceInfos{
+			IfName:       proto.String(fmt.Sprintf("et-0/0/%d", i)),
+			IfHighSpeed:  proto.Uint32(100000),
+			IngressStats: stats(),
+			EgressStats:  stats(),
+		}
+		for q := 0; q < 8; q++ {
+			info.EgressQueueInfo = append(info.EgressQueueInfo, &port.QueueStats{
+				QueueNumber: proto.Uint32(uint32(q)),
+				Packets:     proto.Uint64(uint64(100000 + q)),
+				Bytes:       proto.Uint64(uint64(90000000 + q)),
+			})
+		}
+		p.InterfaceStats = append(p.InterfaceStats, info)
+	}
+
+	jns := &telemetry_top.JuniperNetworksSensors{}
+	assert.NoError(b, proto.SetExtension(jns, port.E_JnprInterfaceExt, p))
+	enterprise := &telemetry_top.EnterpriseSensors{}
+	assert.NoError(b, proto.SetExtension(enterprise, telemetry_top.E_JuniperNetworks, jns))
+
+	data, err := proto.Marshal(&telemetry_top.TelemetryStream{
+		SystemId:   &systemID,
+		Enterprise: enterprise,
+	})
+	assert.NoError(b, err)
+	return data
+}
+
+// BenchmarkJtiUDPServer_process measures the cost of processing a packet for devices
+// which are already registered, which is the steady state of the server.
+func BenchmarkJtiUDPServer_process(b *testing.B) {
+	dm := newIDDeviceManager()
+	svr := NewJtiUDPServer(&config.ServerConfig{Address: "localhost"}, dm)
+	pkt := &packet{data: makeBenchmarkStream(b, "router1", 64)}
+	svr.process(pkt)
+	devices := len(dm.devices)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		svr.process(pkt)
+	}
+	b.StopTimer()
+
+	// No devices are created once the first packet has been processed.
+	if len(dm.devices) != devices {
+		b.Fatalf("expected %d devices, got %d", devices, len(dm.devices))
+	}
+}